@@ -0,0 +1,43 @@
+package achievements
+
+import (
+	"BAZ/Nutritracker/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Rules an achievement can be based on. Each rule compares one Facts value
+// against the threshold of the achievement.
+const (
+	RuleNutrilogCount        = "nutrilog_count"
+	RuleBarcodeNutrilogCount = "barcode_nutrilog_count"
+	RuleGoalStreak           = "goal_streak"
+	RuleFullDayStreak        = "full_day_streak"
+)
+
+// Catalog is the list of achievements users can earn. Add new achievements
+// here, they are synced to the database by SyncCatalog.
+var Catalog = []models.Achievement{
+	{Key: "first_meal", Name: "First bite", Description: "Log your first meal", Rule: RuleNutrilogCount, Threshold: 1},
+	{Key: "meals_100", Name: "Centurion", Description: "Log 100 meals", Rule: RuleNutrilogCount, Threshold: 100},
+	{Key: "first_barcode_meal", Name: "Scanner", Description: "Log a meal by scanning a barcode", Rule: RuleBarcodeNutrilogCount, Threshold: 1},
+	{Key: "goal_streak_3", Name: "On track", Description: "Reach your nutrition goal 3 days in a row", Rule: RuleGoalStreak, Threshold: 3},
+	{Key: "goal_streak_7", Name: "Goal week", Description: "Reach your nutrition goal 7 days in a row", Rule: RuleGoalStreak, Threshold: 7},
+	{Key: "full_days_5", Name: "Three square meals", Description: "Log breakfast, lunch and dinner 5 days in a row", Rule: RuleFullDayStreak, Threshold: 5},
+}
+
+// SyncCatalog inserts new catalog entries and updates changed ones.
+func SyncCatalog(db *gorm.DB) error {
+	for _, achievement := range Catalog {
+		entry := achievement
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description", "rule", "threshold", "updated_at"}),
+		}).Create(&entry).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package achievements
+
+import (
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// fullDayWindow is how many days back full day streaks are looked up.
+const fullDayWindow = 60
+
+// Facts are the numbers about a user that achievement rules are checked against.
+type Facts struct {
+	NutrilogCount        int
+	BarcodeNutrilogCount int
+	GoalStreak           int
+	FullDayStreak        int
+}
+
+// value returns the fact a rule is based on.
+func (f Facts) value(rule string) int {
+	switch rule {
+	case RuleNutrilogCount:
+		return f.NutrilogCount
+	case RuleBarcodeNutrilogCount:
+		return f.BarcodeNutrilogCount
+	case RuleGoalStreak:
+		return f.GoalStreak
+	case RuleFullDayStreak:
+		return f.FullDayStreak
+	}
+	return 0
+}
+
+// Earned reports whether the facts satisfy the rule of an achievement.
+func Earned(achievement models.Achievement, facts Facts) bool {
+	return achievement.Threshold > 0 && facts.value(achievement.Rule) >= achievement.Threshold
+}
+
+// Evaluate awards every achievement the user has earned but not received yet
+// and returns the new ones. now must be in the user's timezone. goalStreak is
+// the current goal streak if the caller already knows it, otherwise it is read
+// from the active nutrition goal.
+func Evaluate(tx *gorm.DB, userID uint, now time.Time, goalStreak int) ([]models.Achievement, error) {
+	facts, err := collectFacts(tx, userID, now, goalStreak)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog []models.Achievement
+	if err := tx.Find(&catalog).Error; err != nil {
+		return nil, err
+	}
+
+	var awarded []models.Achievement
+	err = tx.Transaction(func(tx *gorm.DB) error {
+		for _, achievement := range catalog {
+			if !Earned(achievement, facts) {
+				continue
+			}
+
+			// The unique index on user and achievement makes sure it's only awarded once
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Accomplished_achievements{
+				Date_achieved: now,
+				UserID:        userID,
+				AchievementID: achievement.ID,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				awarded = append(awarded, achievement)
+			}
+		}
+
+		if len(awarded) == 0 {
+			return nil
+		}
+		return stats.RefreshAchievements(tx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return awarded, nil
+}
+
+func collectFacts(tx *gorm.DB, userID uint, now time.Time, goalStreak int) (Facts, error) {
+	facts := Facts{GoalStreak: goalStreak}
+
+	var count int64
+	if err := tx.Model(&models.Nutrilog{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return facts, err
+	}
+	facts.NutrilogCount = int(count)
+
+	if err := tx.Model(&models.Nutrilog{}).Where("user_id = ? AND source = ?", userID, models.NutrilogSourceBarcode).Count(&count).Error; err != nil {
+		return facts, err
+	}
+	facts.BarcodeNutrilogCount = int(count)
+
+	if facts.GoalStreak == 0 {
+		var nutritionGoal models.NutritionGoal
+		if err := tx.Where("user_id = ? AND is_active = ?", userID, true).First(&nutritionGoal).Error; err == nil {
+			facts.GoalStreak = nutritionGoal.GoalAchievedDays
+		}
+	}
+
+	var meals []struct {
+		MealDate models.Date
+		MealType string
+	}
+	from := models.DateOf(now).AddDays(-fullDayWindow)
+	err := tx.Model(&models.Nutrilog{}).
+		Distinct("meal_date", "meal_type").
+		Where("user_id = ? AND meal_date >= ?", userID, from).
+		Find(&meals).Error
+	if err != nil {
+		return facts, err
+	}
+
+	mealsByDate := map[models.Date]map[string]bool{}
+	for _, meal := range meals {
+		if mealsByDate[meal.MealDate] == nil {
+			mealsByDate[meal.MealDate] = map[string]bool{}
+		}
+		mealsByDate[meal.MealDate][meal.MealType] = true
+	}
+	facts.FullDayStreak = fullDayStreak(mealsByDate, now)
+
+	return facts, nil
+}
+
+// fullDayStreak counts the days in a row, up to and including today, on which
+// breakfast, lunch and dinner were all logged. An incomplete today doesn't
+// break the streak since the day isn't over yet.
+func fullDayStreak(mealsByDate map[models.Date]map[string]bool, now time.Time) int {
+	isFullDay := func(day models.Date) bool {
+		meals := mealsByDate[day]
+		return meals["breakfast"] && meals["lunch"] && meals["dinner"]
+	}
+
+	streak := 0
+	day := models.DateOf(now)
+	if !isFullDay(day) {
+		day = day.AddDays(-1)
+	}
+	for isFullDay(day) {
+		streak++
+		day = day.AddDays(-1)
+	}
+	return streak
+}
@@ -0,0 +1,50 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/models"
+	"log"
+	"os"
+	"strings"
+)
+
+// ProvidersFromEnv builds the remote providers listed in BARCODE_PROVIDERS, in
+// order. Defaults to Open Food Facts, whose URL can be set with OPENFOODFACTS_URL.
+func ProvidersFromEnv() []Provider {
+	names := os.Getenv("BARCODE_PROVIDERS")
+	if names == "" {
+		names = models.FoodSourceOpenFoodFacts
+	}
+
+	var providers []Provider
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case models.FoodSourceOpenFoodFacts:
+			providers = append(providers, NewOpenFoodFactsProvider(os.Getenv("OPENFOODFACTS_URL")))
+		case "fake":
+			providers = append(providers, FakeProvider{Products: DemoProducts()})
+		case "none", "":
+		default:
+			log.Printf("Unknown barcode provider %q, ignoring it", name)
+		}
+	}
+	return providers
+}
+
+// DemoProducts are a few well known products for the fake provider.
+func DemoProducts() map[string]models.Food {
+	product := func(code, name, brand, unit string, kcal, protein, fat, carbs float64, serving string, quantity float64) models.Food {
+		barcode, sourceID := code, code
+		return models.Food{
+			Name: name, Brand: brand, Barcode: &barcode, Unit: unit,
+			CaloriesPer100: kcal, ProteinsPer100: protein, FatsPer100: fat, CarbohydratesPer100: carbs,
+			Servings: []models.FoodServing{{Name: serving, Quantity: quantity}},
+			Source:   "fake", SourceID: &sourceID,
+		}
+	}
+	return map[string]models.Food{
+		"5449000000996": product("5449000000996", "Coca-Cola", "Coca-Cola", models.FoodUnitMilliliter, 42, 0, 0, 10.6, "1 can (330 ml)", 330),
+		"8710398500395": product("8710398500395", "Greek Yogurt", "", models.FoodUnitGram, 100, 10, 5.3, 4, "1 pot (150 g)", 150),
+		"3017620422003": product("3017620422003", "Nutella", "Ferrero", models.FoodUnitGram, 539, 6.3, 30.9, 57.5, "1 portion (15 g)", 15),
+		"5000159459228": product("5000159459228", "Snickers", "Mars", models.FoodUnitGram, 488, 8.6, 23.5, 60.5, "1 bar (50 g)", 50),
+	}
+}
@@ -0,0 +1,24 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/models"
+	"context"
+)
+
+// FakeProvider serves products from memory. It is meant for tests and local
+// development without network access.
+type FakeProvider struct {
+	Products map[string]models.Food
+}
+
+func (p FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p FakeProvider) Lookup(ctx context.Context, code string) (models.Food, error) {
+	food, ok := p.Products[code]
+	if !ok {
+		return models.Food{}, ErrNotFound
+	}
+	return food, nil
+}
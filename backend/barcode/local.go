@@ -0,0 +1,28 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// LocalProvider looks up barcodes in the foods table. Products found by other
+// providers are cached there, so it also serves as the cache.
+type LocalProvider struct {
+	DB *gorm.DB
+}
+
+func (p LocalProvider) Name() string {
+	return "local"
+}
+
+func (p LocalProvider) Lookup(ctx context.Context, code string) (models.Food, error) {
+	var food models.Food
+	err := p.DB.WithContext(ctx).Preload("Servings").Where("barcode = ?", code).First(&food).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Food{}, ErrNotFound
+	}
+	return food, err
+}
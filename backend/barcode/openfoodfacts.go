@@ -0,0 +1,180 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultOpenFoodFactsURL = "https://world.openfoodfacts.org"
+
+// OpenFoodFactsProvider looks up products with the Open Food Facts product API.
+// Any server implementing the same API can be used by changing BaseURL.
+type OpenFoodFactsProvider struct {
+	BaseURL   string
+	UserAgent string
+	Client    *http.Client
+}
+
+// NewOpenFoodFactsProvider returns a provider for the given API base URL.
+func NewOpenFoodFactsProvider(baseURL string) OpenFoodFactsProvider {
+	if baseURL == "" {
+		baseURL = DefaultOpenFoodFactsURL
+	}
+	return OpenFoodFactsProvider{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		UserAgent: "Nutritracker/1.0",
+		Client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (p OpenFoodFactsProvider) Name() string {
+	return models.FoodSourceOpenFoodFacts
+}
+
+// OpenFoodFactsProduct is the part of an Open Food Facts product we use. The
+// API and the JSONL data export share this format.
+type OpenFoodFactsProduct struct {
+	Code            string                  `json:"code"`
+	ProductName     string                  `json:"product_name"`
+	Brands          string                  `json:"brands"`
+	ServingSize     string                  `json:"serving_size"`
+	ServingQuantity FlexibleNum             `json:"serving_quantity"`
+	Nutriments      OpenFoodFactsNutriments `json:"nutriments"`
+}
+
+// OpenFoodFactsNutriments are the nutriments of a product by field name, such
+// as energy-kcal_100g. Amounts per 100 g are in g, energy_100g is in kJ.
+type OpenFoodFactsNutriments map[string]FlexibleNum
+
+// openFoodFactsNutrients maps Open Food Facts nutriments to tracked nutrients,
+// with the factor to convert from g to the unit of the nutrient.
+var openFoodFactsNutrients = map[string]struct {
+	key    string
+	factor float64
+}{
+	"fiber_100g":         {nutrients.Fiber, 1},
+	"sugars_100g":        {nutrients.Sugar, 1},
+	"saturated-fat_100g": {nutrients.SaturatedFat, 1},
+	"sodium_100g":        {nutrients.Sodium, 1e3},
+	"cholesterol_100g":   {nutrients.Cholesterol, 1e3},
+	"potassium_100g":     {nutrients.Potassium, 1e3},
+	"calcium_100g":       {nutrients.Calcium, 1e3},
+	"iron_100g":          {nutrients.Iron, 1e3},
+	"vitamin-a_100g":     {nutrients.VitaminA, 1e6},
+	"vitamin-c_100g":     {nutrients.VitaminC, 1e3},
+	"vitamin-d_100g":     {nutrients.VitaminD, 1e6},
+}
+
+// otherNutrients converts the nutriments besides energy and macros. Missing
+// and zero values are left out as the export doesn't tell them apart.
+func (n OpenFoodFactsNutriments) otherNutrients() models.NutrientAmounts {
+	amounts := models.NutrientAmounts{}
+	for field, nutrient := range openFoodFactsNutrients {
+		if value := float64(n[field]); value > 0 {
+			amounts[nutrient.key] = value * nutrient.factor
+		}
+	}
+	if len(amounts) == 0 {
+		return nil
+	}
+	return amounts.Rounded()
+}
+
+// FlexibleNum accepts numbers that Open Food Facts sometimes sends as strings.
+type FlexibleNum float64
+
+func (n *FlexibleNum) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*n = 0
+		return nil
+	}
+	var value float64
+	if _, err := fmt.Sscanf(text, "%g", &value); err != nil {
+		*n = 0
+		return nil
+	}
+	*n = FlexibleNum(value)
+	return nil
+}
+
+func (p OpenFoodFactsProvider) Lookup(ctx context.Context, code string) (models.Food, error) {
+	url := fmt.Sprintf("%s/api/v2/product/%s.json?fields=code,product_name,brands,serving_size,serving_quantity,nutriments", p.BaseURL, code)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return models.Food{}, err
+	}
+	req.Header.Set("User-Agent", p.UserAgent)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return models.Food{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return models.Food{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return models.Food{}, fmt.Errorf("open food facts returned status %d", resp.StatusCode)
+	}
+
+	var payload struct {
+		Status  int                  `json:"status"`
+		Product OpenFoodFactsProduct `json:"product"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return models.Food{}, err
+	}
+	if payload.Status != 1 || payload.Product.ProductName == "" {
+		return models.Food{}, ErrNotFound
+	}
+
+	return FoodFromOpenFoodFacts(code, payload.Product), nil
+}
+
+// FoodFromOpenFoodFacts converts an Open Food Facts product to a catalog food.
+func FoodFromOpenFoodFacts(code string, product OpenFoodFactsProduct) models.Food {
+	nutriments := product.Nutriments
+	calories := float64(nutriments["energy-kcal_100g"])
+	if calories == 0 && nutriments["energy_100g"] > 0 {
+		calories = float64(nutriments["energy_100g"]) / 4.184
+	}
+
+	barcode, sourceID := code, code
+	food := models.Food{
+		Name:                strings.TrimSpace(product.ProductName),
+		Brand:               firstBrand(product.Brands),
+		Barcode:             &barcode,
+		Unit:                models.FoodUnitGram,
+		CaloriesPer100:      calories,
+		ProteinsPer100:      float64(nutriments["proteins_100g"]),
+		FatsPer100:          float64(nutriments["fat_100g"]),
+		CarbohydratesPer100: float64(nutriments["carbohydrates_100g"]),
+		NutrientsPer100:     nutriments.otherNutrients(),
+		Source:              models.FoodSourceOpenFoodFacts,
+		SourceID:            &sourceID,
+	}
+
+	if strings.Contains(strings.ToLower(product.ServingSize), "ml") {
+		food.Unit = models.FoodUnitMilliliter
+	}
+	if product.ServingQuantity > 0 {
+		food.Servings = []models.FoodServing{{
+			Name:     "1 serving (" + product.ServingSize + ")",
+			Quantity: float64(product.ServingQuantity),
+		}}
+	}
+	return food
+}
+
+// firstBrand returns the first of a comma separated list of brands.
+func firstBrand(brands string) string {
+	return strings.TrimSpace(strings.Split(brands, ",")[0])
+}
@@ -0,0 +1,28 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/models"
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ErrNotFound is returned by a provider that doesn't know a barcode.
+var ErrNotFound = errors.New("product not found")
+
+// ErrInvalidBarcode is returned for codes that can't be an EAN/UPC barcode.
+var ErrInvalidBarcode = errors.New("invalid barcode")
+
+var barcodePattern = regexp.MustCompile(`^[0-9]{8,14}$`)
+
+// Provider looks up a product by its barcode. Implementations return
+// ErrNotFound when they don't know the product.
+type Provider interface {
+	Name() string
+	Lookup(ctx context.Context, code string) (models.Food, error)
+}
+
+// ValidBarcode reports whether code looks like an EAN-8, UPC-A, EAN-13 or GTIN-14 code.
+func ValidBarcode(code string) bool {
+	return barcodePattern.MatchString(code)
+}
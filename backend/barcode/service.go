@@ -0,0 +1,100 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// missTTL is how long an unknown barcode isn't looked up remotely again.
+const missTTL = 24 * time.Hour
+
+// Service looks a barcode up in the local catalog first and then asks the
+// remote providers in order. Products found remotely are stored in the catalog.
+type Service struct {
+	DB        *gorm.DB
+	Providers []Provider
+}
+
+// Lookup returns the food for a barcode and the name of the provider that
+// knew it, or ErrNotFound if none did. If no provider knew it and one of them
+// failed, the error of the first failing provider is returned and the miss
+// isn't remembered, as that provider might know the barcode.
+func (s Service) Lookup(ctx context.Context, code string) (models.Food, string, error) {
+	if !ValidBarcode(code) {
+		return models.Food{}, "", ErrInvalidBarcode
+	}
+
+	local := LocalProvider{DB: s.DB}
+	food, err := local.Lookup(ctx, code)
+	if err == nil {
+		return food, local.Name(), nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return models.Food{}, "", err
+	}
+
+	if s.recentlyMissed(code) {
+		return models.Food{}, "", ErrNotFound
+	}
+
+	var failed error
+	for _, provider := range s.Providers {
+		food, err := provider.Lookup(ctx, code)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			// A provider being down shouldn't stop the others from answering
+			log.Printf("Barcode provider %s failed for %s: %v", provider.Name(), code, err)
+			if failed == nil {
+				failed = fmt.Errorf("barcode provider %s: %w", provider.Name(), err)
+			}
+			continue
+		}
+
+		if err := s.cache(&food, code); err != nil {
+			log.Printf("Error caching barcode %s: %v", code, err)
+		}
+		return food, provider.Name(), nil
+	}
+
+	if failed != nil {
+		return models.Food{}, "", failed
+	}
+	s.rememberMiss(code)
+	return models.Food{}, "", ErrNotFound
+}
+
+// cache stores a remotely found food in the catalog.
+func (s Service) cache(food *models.Food, code string) error {
+	food.ID = 0
+	food.Barcode = &code
+	for i := range food.Servings {
+		food.Servings[i].ID = 0
+	}
+	return s.DB.Create(food).Error
+}
+
+func (s Service) recentlyMissed(code string) bool {
+	var miss models.BarcodeLookupMiss
+	err := s.DB.Where("barcode = ? AND checked_at > ?", code, time.Now().Add(-missTTL)).First(&miss).Error
+	return err == nil
+}
+
+func (s Service) rememberMiss(code string) {
+	miss := models.BarcodeLookupMiss{Barcode: code}
+	s.DB.Where("barcode = ?", code).FirstOrInit(&miss)
+	miss.CheckedAt = time.Now()
+	s.DB.Save(&miss)
+}
+
+// ForgetMiss clears the remembered miss once a product was submitted for the barcode.
+func (s Service) ForgetMiss(code string) {
+	s.DB.Unscoped().Where("barcode = ?", code).Delete(&models.BarcodeLookupMiss{})
+}
@@ -0,0 +1,129 @@
+package barcode
+
+import (
+	"BAZ/Nutritracker/internal/fakedb"
+	"BAZ/Nutritracker/models"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+const testBarcode = "4006381333931"
+
+// failingProvider is a provider that is down.
+type failingProvider struct{}
+
+func (failingProvider) Name() string {
+	return "failing"
+}
+
+func (failingProvider) Lookup(ctx context.Context, code string) (models.Food, error) {
+	return models.Food{}, errors.New("service unavailable")
+}
+
+func TestServiceLookup(t *testing.T) {
+	known := FakeProvider{Products: map[string]models.Food{
+		testBarcode: {Name: "Oat drink", CaloriesPer100: 46},
+	}}
+	unknown := FakeProvider{}
+
+	tests := []struct {
+		name         string
+		code         string
+		providers    []Provider
+		wantProvider string
+		wantErr      error
+		wantFailure  bool
+		wantMiss     bool
+		wantCached   bool
+	}{
+		{
+			name:    "invalid barcode",
+			code:    "12ab",
+			wantErr: ErrInvalidBarcode,
+		},
+		{
+			name:         "known by a provider",
+			code:         testBarcode,
+			providers:    []Provider{unknown, known},
+			wantProvider: "fake",
+			wantCached:   true,
+		},
+		{
+			name:         "known after a provider failed",
+			code:         testBarcode,
+			providers:    []Provider{failingProvider{}, known},
+			wantProvider: "fake",
+			wantCached:   true,
+		},
+		{
+			name:      "unknown to every provider",
+			code:      testBarcode,
+			providers: []Provider{unknown, unknown},
+			wantErr:   ErrNotFound,
+			wantMiss:  true,
+		},
+		{
+			name:        "unknown while a provider failed",
+			code:        testBarcode,
+			providers:   []Provider{unknown, failingProvider{}},
+			wantFailure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, gormDB, err := fakedb.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			db.On("foods", []string{"id"})
+			db.On("barcode_lookup_misses", []string{"id"})
+			service := Service{DB: gormDB, Providers: tt.providers}
+
+			food, provider, err := service.Lookup(context.Background(), tt.code)
+			switch {
+			case tt.wantFailure:
+				if err == nil || errors.Is(err, ErrNotFound) {
+					t.Errorf("Lookup() error = %v, want the provider failure", err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Errorf("Lookup() error = %v, want %v", err, tt.wantErr)
+			}
+			if provider != tt.wantProvider {
+				t.Errorf("Lookup() provider = %q, want %q", provider, tt.wantProvider)
+			}
+			if tt.wantProvider != "" && (food.Barcode == nil || *food.Barcode != tt.code) {
+				t.Errorf("Lookup() food barcode = %v, want %s", food.Barcode, tt.code)
+			}
+
+			missed := len(db.ExecutedOn("barcode_lookup_misses")) > 0
+			cached := len(db.ExecutedOn("foods")) > 0
+			if missed != tt.wantMiss {
+				t.Errorf("miss remembered = %v, want %v", missed, tt.wantMiss)
+			}
+			if cached != tt.wantCached {
+				t.Errorf("food cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestServiceLookupLocalFirst(t *testing.T) {
+	db, gormDB, err := fakedb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.On("foods", []string{"id", "name", "barcode"}, []driver.Value{int64(3), "Oat drink", testBarcode})
+	db.On("food_servings", []string{"id", "food_id"})
+	service := Service{DB: gormDB, Providers: []Provider{failingProvider{}}}
+
+	food, provider, err := service.Lookup(context.Background(), testBarcode)
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if provider != "local" || food.ID != 3 {
+		t.Errorf("Lookup() = %d from %q, want 3 from local", food.ID, provider)
+	}
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAchievements lists the whole achievement catalog and marks the ones the
+// authenticated user has earned
+func GetAchievements(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var catalog []models.Achievement
+	if err := initializers.DB.Order("id").Find(&catalog).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	var earned []models.Accomplished_achievements
+	if err := initializers.DB.Where("user_id = ?", user.ID).Find(&earned).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	earnedAt := make(map[uint]time.Time, len(earned))
+	for _, accomplished := range earned {
+		earnedAt[accomplished.AchievementID] = accomplished.Date_achieved
+	}
+
+	type achievementStatus struct {
+		models.Achievement
+		Earned       bool       `json:"earned"`
+		DateAchieved *time.Time `json:"date_achieved"`
+	}
+
+	result := make([]achievementStatus, 0, len(catalog))
+	for _, achievement := range catalog {
+		status := achievementStatus{Achievement: achievement}
+		if date, ok := earnedAt[achievement.ID]; ok {
+			status.Earned = true
+			status.DateAchieved = &date
+		}
+		result = append(result, status)
+	}
+
+	c.JSON(200, gin.H{"achievements": result})
+}
+
+// GetEarnedAchievements lists the achievements of a user, newest first
+func GetEarnedAchievements(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionRead)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var earned []models.Accomplished_achievements
+	result := initializers.DB.Preload("Achievement").
+		Where("user_id = ?", userID).
+		Order("date_achieved DESC").
+		Find(&earned)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
+	c.JSON(200, gin.H{"achievements": earned})
+}
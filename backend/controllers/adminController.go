@@ -0,0 +1,348 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"BAZ/Nutritracker/stats"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminListUsers lists all users, optionally filtered by the role query parameter
+func AdminListUsers(c *gin.Context) {
+	role := c.Query("role")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Order("id")
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+
+	c.JSON(200, gin.H{"users": users})
+}
+
+// AdminGetUser returns a single user by ID
+func AdminGetUser(c *gin.Context) {
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"user": user})
+}
+
+// AdminUpdateUserRole changes the role of a user
+func AdminUpdateUserRole(c *gin.Context) {
+	id := c.Param("id")
+
+	var body struct {
+		Role string `json:"role"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if !models.IsValidRole(body.Role) {
+		c.JSON(400, gin.H{"error": "Invalid role"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Model(&models.User{}).Where("id = ?", id).Update("role", body.Role)
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Role updated successfully"})
+}
+
+// AdminDeleteUser deletes a user and revokes all of their sessions
+func AdminDeleteUser(c *gin.Context) {
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Delete(&models.User{}, id)
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete user"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := revokeSessions(initializers.DB.Where("user_id = ?", id)); err != nil {
+		c.JSON(500, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User deleted successfully"})
+}
+
+// AdminGetUserGoals lists every nutrition goal of a user, newest first
+func AdminGetUserGoals(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var goals []models.NutritionGoal
+	if err := initializers.DB.Where("user_id = ?", uint(userID)).Order("effective_from DESC, id DESC").Find(&goals).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrition goals"})
+		return
+	}
+
+	c.JSON(200, gin.H{"nutrition_goals": goals})
+}
+
+// AdminSetUserGoal replaces the active nutrition goal of a user
+func AdminSetUserGoal(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		CaloriesGoal  int                    `json:"calories_goal"`
+		ProteinsGoal  int                    `json:"proteins_goal"`
+		FatsGoal      int                    `json:"fats_goal"`
+		CarbsGoal     int                    `json:"carbs_goal"`
+		NutrientGoals models.NutrientAmounts `json:"nutrient_goals"`
+		Ranges        models.GoalRanges      `json:"ranges"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if err := nutrients.Validate(body.NutrientGoals); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := stats.ValidateGoalRanges(body.Ranges); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, uint(userID)).Error; err != nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	// Replaces the active goal, which stays in the history
+	nutritionGoal := models.NutritionGoal{
+		UserID:        user.ID,
+		CaloriesGoal:  body.CaloriesGoal,
+		ProteinsGoal:  body.ProteinsGoal,
+		FatsGoal:      body.FatsGoal,
+		CarbsGoal:     body.CarbsGoal,
+		NutrientGoals: body.NutrientGoals.Rounded(),
+		Ranges:        body.Ranges,
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceNutritionGoal(tx, &nutritionGoal, userNow(user.ID))
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create nutrition goal"})
+		return
+	}
+	refreshTodaySummary(user.ID)
+
+	c.JSON(200, gin.H{
+		"message":        "Nutrition goal created",
+		"nutrition_goal": nutritionGoal,
+	})
+}
+
+// AdminListMessageTemplates lists the motivational message catalog
+func AdminListMessageTemplates(c *gin.Context) {
+	messageType := c.Query("message_type")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Order("id")
+	if messageType != "" {
+		query = query.Where("message_type = ?", messageType)
+	}
+
+	var templates []models.MessageTemplate
+	if err := query.Find(&templates).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch message templates"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message_templates": templates})
+}
+
+// AdminCreateMessageTemplate adds a message to the catalog
+func AdminCreateMessageTemplate(c *gin.Context) {
+	var body struct {
+		Message      string `json:"message"`
+		MessageType  string `json:"message_type"`
+		ScheduledFor string `json:"scheduled_for"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if body.Message == "" {
+		c.JSON(400, gin.H{"error": "Message is required"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	template := models.MessageTemplate{
+		Message:      body.Message,
+		MessageType:  body.MessageType,
+		ScheduledFor: body.ScheduledFor,
+		IsActive:     true,
+	}
+
+	if err := initializers.DB.Create(&template).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create message template"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":          "Message template created",
+		"message_template": template,
+	})
+}
+
+// AdminUpdateMessageTemplate updates a message in the catalog
+func AdminUpdateMessageTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	var body struct {
+		Message      string  `json:"message"`
+		MessageType  string  `json:"message_type"`
+		ScheduledFor *string `json:"scheduled_for"`
+		IsActive     *bool   `json:"is_active"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var template models.MessageTemplate
+	if err := initializers.DB.First(&template, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Message template not found"})
+		return
+	}
+
+	if body.Message != "" {
+		template.Message = body.Message
+	}
+	if body.MessageType != "" {
+		template.MessageType = body.MessageType
+	}
+	if body.ScheduledFor != nil {
+		template.ScheduledFor = *body.ScheduledFor
+	}
+	if body.IsActive != nil {
+		template.IsActive = *body.IsActive
+	}
+
+	if err := initializers.DB.Save(&template).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update message template"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":          "Message template updated successfully",
+		"message_template": template,
+	})
+}
+
+// AdminDeleteMessageTemplate removes a message from the catalog
+func AdminDeleteMessageTemplate(c *gin.Context) {
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Delete(&models.MessageTemplate{}, id)
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete message template"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Message template not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Message template deleted successfully"})
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/helpers"
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// issueSession creates a new session for the user and returns a signed access
+// token together with the refresh token that belongs to it.
+func issueSession(c *gin.Context, user models.User) (string, string, error) {
+	refreshToken, refreshHash, err := helpers.NewRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	session := models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: refreshHash,
+		UserAgent:        c.Request.UserAgent(),
+		ExpiresAt:        time.Now().Add(helpers.RefreshTokenTTL),
+	}
+	if err := initializers.DB.Create(&session).Error; err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := helpers.NewAccessToken(user.ID, session.ID)
+	if err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+// setAuthCookies stores both tokens in httpOnly cookies for browser clients.
+func setAuthCookies(c *gin.Context, accessToken string, refreshToken string) {
+	c.SetCookie(
+		"usertoken",
+		accessToken,
+		int(helpers.AccessTokenTTL.Seconds()),
+		"/",
+		"",
+		true, // secure
+		true, // httpOnly
+	)
+	c.SetCookie(
+		"refreshtoken",
+		refreshToken,
+		int(helpers.RefreshTokenTTL.Seconds()),
+		"/",
+		"",
+		true, // secure
+		true, // httpOnly
+	)
+}
+
+func clearAuthCookies(c *gin.Context) {
+	c.SetCookie("usertoken", "", -1, "/", "", true, true)
+	c.SetCookie("refreshtoken", "", -1, "/", "", true, true)
+}
+
+// RefreshToken exchanges a valid refresh token for a new access token. The
+// refresh token is rotated on every call, so each one can only be used once.
+func RefreshToken(c *gin.Context) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	c.ShouldBindJSON(&body)
+
+	// Fall back to the cookie for browser clients
+	if body.RefreshToken == "" {
+		if cookie, err := c.Cookie("refreshtoken"); err == nil {
+			body.RefreshToken = cookie
+		}
+	}
+
+	if body.RefreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token required"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var session models.UserSession
+	result := initializers.DB.Where("refresh_token_hash = ?", helpers.HashToken(body.RefreshToken)).First(&session)
+	if result.Error != nil || !session.IsActive(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	refreshToken, refreshHash, err := helpers.NewRefreshToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate refresh token"})
+		return
+	}
+
+	// Rotate the refresh token, guarding against a concurrent refresh with the same token
+	now := time.Now()
+	update := initializers.DB.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, session.RefreshTokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": refreshHash,
+			"last_used_at":       now,
+			"expires_at":         now.Add(helpers.RefreshTokenTTL),
+		})
+	if update.Error != nil || update.RowsAffected == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+
+	accessToken, err := helpers.NewAccessToken(session.UserID, session.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to sign token"})
+		return
+	}
+
+	setAuthCookies(c, accessToken, refreshToken)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         accessToken,
+		"refresh_token": refreshToken,
+		"expires_in":    int(helpers.AccessTokenTTL.Seconds()),
+	})
+}
+
+// Logout revokes the session the current access token belongs to.
+func Logout(c *gin.Context) {
+	value, exists := c.Get("session")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	session := value.(models.UserSession)
+
+	if err := revokeSessions(initializers.DB.Where("id = ?", session.ID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+
+	clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// LogoutAll revokes every session of the authenticated user, signing out all
+// devices at once.
+func LogoutAll(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	authenticatedUser := user.(models.User)
+
+	if err := revokeSessions(initializers.DB.Where("user_id = ?", authenticatedUser.ID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+		return
+	}
+
+	clearAuthCookies(c)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out on all devices"})
+}
+
+// revokeSessions marks every still-active session matched by query as revoked.
+func revokeSessions(query *gorm.DB) error {
+	return query.Model(&models.UserSession{}).Where("revoked_at IS NULL").Update("revoked_at", time.Now()).Error
+}
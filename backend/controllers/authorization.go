@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Permission is the kind of access a user asks for on another user's data.
+// Reads of data a patient chooses whether to share with a guardian have their
+// own permission, so grants can follow the sharing settings.
+type Permission string
+
+const (
+	PermissionRead             Permission = "read" // summaries such as streaks and achievements
+	PermissionReadGoals        Permission = "read_goals"
+	PermissionReadMeals        Permission = "read_meals"        // nutrilogs with their descriptions
+	PermissionReadMeasurements Permission = "read_measurements" // measurements, body metrics and what is computed from them
+	PermissionReadMessages     Permission = "read_messages"
+	PermissionWrite            Permission = "write"
+)
+
+// AccessGrant decides whether actor may access the data of the user with
+// subjectID. Grants are the only way to reach data of another user.
+type AccessGrant func(actor models.User, subjectID uint, permission Permission) bool
+
+var accessGrants []AccessGrant
+
+// RegisterAccessGrant adds a rule that allows cross-user access, such as a
+// guardian link between two users.
+func RegisterAccessGrant(grant AccessGrant) {
+	accessGrants = append(accessGrants, grant)
+}
+
+// CanAccessUser reports whether actor may access the data of subjectID.
+// Users always have full access to their own data.
+func CanAccessUser(actor models.User, subjectID uint, permission Permission) bool {
+	if actor.ID == subjectID {
+		return true
+	}
+	for _, grant := range accessGrants {
+		if grant(actor, subjectID, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// currentUser returns the user set by RequireAuth and responds with 401 if
+// there is none.
+func currentUser(c *gin.Context) (models.User, bool) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return models.User{}, false
+	}
+	return user.(models.User), true
+}
+
+// authorizeSubject resolves the user whose data is requested from a path
+// parameter. An empty rawID means the authenticated user. Responds with 400 or
+// 403 and returns false when the request must stop.
+func authorizeSubject(c *gin.Context, rawID string, permission Permission) (uint, bool) {
+	if rawID == "" {
+		return authorizeSubjectID(c, 0, permission)
+	}
+
+	subjectID, err := strconv.ParseUint(rawID, 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+
+	return authorizeSubjectID(c, uint(subjectID), permission)
+}
+
+// authorizeSubjectID is authorizeSubject for IDs taken from a request body,
+// where 0 means the authenticated user.
+func authorizeSubjectID(c *gin.Context, subjectID uint, permission Permission) (uint, bool) {
+	actor, ok := currentUser(c)
+	if !ok {
+		return 0, false
+	}
+
+	if subjectID == 0 {
+		return actor.ID, true
+	}
+
+	if !CanAccessUser(actor, subjectID, permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to access this user's data"})
+		return 0, false
+	}
+
+	return subjectID, true
+}
+
+// authorizeOwner checks access to a single record owned by ownerID. Records the
+// user may not access are reported as not found so their existence isn't leaked.
+func authorizeOwner(c *gin.Context, ownerID uint, permission Permission, notFound string) bool {
+	actor, ok := currentUser(c)
+	if !ok {
+		return false
+	}
+
+	if !CanAccessUser(actor, ownerID, permission) {
+		c.JSON(404, gin.H{"error": notFound})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/internal/fakedb"
+	"BAZ/Nutritracker/models"
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	patientID  = 1
+	guardianID = 2
+	strangerID = 3
+)
+
+// Actors of the authorization tests.
+const (
+	actorOwner           = "owner"
+	actorStranger        = "stranger"
+	actorSharingGuardian = "guardian the patient shares everything with"
+	actorPrivateGuardian = "guardian the patient shares nothing with"
+)
+
+var actors = []string{actorOwner, actorStranger, actorSharingGuardian, actorPrivateGuardian}
+
+// authorizationRouter serves the goal, nutrilog and message routes as the given user.
+func authorizationRouter(actorID uint) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", models.User{Model: gorm.Model{ID: actorID}})
+	})
+	router.POST("/createnutritiongoal", CreateNutritionGoal)
+	router.GET("/getnutritiongoal/:user_id", GetActiveNutritionGoal)
+	router.PUT("/updatenutritiongoal/:id", UpdateNutritionGoal)
+	router.POST("/checkgoalprogress/:user_id", CheckAndUpdateGoalProgress)
+	router.GET("/goals/history", GetGoalHistory)
+	router.GET("/getnutrilogs/:user_id", GetNutrilogsByUserAndDate)
+	router.POST("/createmotivationalmessage", CreateMotivationalMessage)
+	router.GET("/motivationalmessages/:user_id", GetMotivationalMessagesByUser)
+	router.GET("/unreadmotivationalmessages/:user_id", GetUnreadMotivationalMessagesByUser)
+	router.GET("/timedmotivationalmessages/:user_id", GetTimedMotivationalMessages)
+	router.PUT("/markmessageasread/:id", MarkMessageAsRead)
+	router.DELETE("/deletemotivationalmessage/:id", DeleteMotivationalMessage)
+	return router
+}
+
+// actorID returns the user an actor is logged in as and answers their guardian
+// link lookups.
+func actorID(db *fakedb.DB, actor string) uint {
+	switch actor {
+	case actorOwner:
+		return patientID
+	case actorSharingGuardian:
+		guardianLink(db, true)
+		return guardianID
+	case actorPrivateGuardian:
+		guardianLink(db, false)
+		return guardianID
+	}
+	db.On("guardians", []string{"id"})
+	return strangerID
+}
+
+// guardianLink answers the guardian link lookup with a link sharing everything or nothing.
+func guardianLink(db *fakedb.DB, share bool) {
+	db.On("guardians",
+		[]string{"id", "patient_id", "guardian_id", "share_goals", "share_meals", "share_meal_descriptions", "share_measurements"},
+		[]driver.Value{int64(1), int64(patientID), int64(guardianID), share, share, share, share},
+	)
+}
+
+// patientData answers the queries of the routes under test with the data of
+// the patient: an active goal, a nutrilog and a message.
+func patientData(db *fakedb.DB) {
+	db.On("users", []string{"id", "timezone"}, []driver.Value{int64(patientID), "UTC"})
+	db.On("nutrition_goals",
+		[]string{"id", "user_id", "calories_goal", "proteins_goal", "fats_goal", "carbs_goal", "is_active", "effective_from"},
+		[]driver.Value{int64(7), int64(patientID), int64(2000), int64(75), int64(65), int64(250), true, "2026-10-01"},
+	)
+	db.OnQuery("SELECT count(*) FROM `nutrilogs`", []string{"count"}, []driver.Value{int64(1)})
+	db.On("nutrilogs",
+		[]string{"id", "user_id", "calories", "meal_type", "meal_date"},
+		[]driver.Value{int64(11), int64(patientID), 450.5, "lunch", "2026-10-18"},
+	)
+	db.On("motivational_messages",
+		[]string{"id", "user_id", "message", "message_type", "is_read"},
+		[]driver.Value{int64(9), int64(patientID), "Keep going!", "general", false},
+	)
+	noRows(db, "daily_summaries", "goal_progression_policies", "goal_changes", "stats",
+		"achievements", "accomplished_achievements")
+}
+
+// noRows answers the queries on the tables with no rows.
+func noRows(db *fakedb.DB, tables ...string) {
+	for _, table := range tables {
+		db.On(table, []string{"id"})
+	}
+}
+
+func serve(router *gin.Engine, method string, path string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRoutesAuthorization(t *testing.T) {
+	tests := []struct {
+		method     string
+		path       string
+		body       string
+		table      string // table a successful write changes
+		wantStatus map[string]int
+	}{
+		{
+			method: http.MethodPost, path: "/createnutritiongoal", body: `{"user_id": 1, "calories_goal": 1800}`, table: "nutrition_goals",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 403, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodGet, path: "/getnutritiongoal/1",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 200, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodPut, path: "/updatenutritiongoal/7", body: `{"calories_goal": 1900}`, table: "nutrition_goals",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 404, actorSharingGuardian: 404, actorPrivateGuardian: 404},
+		},
+		{
+			method: http.MethodPost, path: "/checkgoalprogress/1",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 403, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodGet, path: "/goals/history?user_id=1",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 200, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodGet, path: "/getnutrilogs/1?date=2026-10-18",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 200, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodPost, path: "/createmotivationalmessage", body: `{"user_id": 1, "message": "You can do it", "message_type": "general"}`, table: "motivational_messages",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 403, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodGet, path: "/motivationalmessages/1",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 403, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodGet, path: "/unreadmotivationalmessages/1",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 403, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodGet, path: "/timedmotivationalmessages/1",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 403, actorSharingGuardian: 403, actorPrivateGuardian: 403},
+		},
+		{
+			method: http.MethodPut, path: "/markmessageasread/9", table: "motivational_messages",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 404, actorSharingGuardian: 404, actorPrivateGuardian: 404},
+		},
+		{
+			method: http.MethodDelete, path: "/deletemotivationalmessage/9", table: "motivational_messages",
+			wantStatus: map[string]int{actorOwner: 200, actorStranger: 404, actorSharingGuardian: 404, actorPrivateGuardian: 404},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, actor := range actors {
+			t.Run(tt.method+" "+tt.path+" as "+actor, func(t *testing.T) {
+				db := useFakeDB(t)
+				router := authorizationRouter(actorID(db, actor))
+				patientData(db)
+
+				recorder := serve(router, tt.method, tt.path, tt.body)
+				want := tt.wantStatus[actor]
+				if recorder.Code != want {
+					t.Fatalf("%s %s = %d, want %d: %s", tt.method, tt.path, recorder.Code, want, recorder.Body)
+				}
+
+				switch {
+				case want >= 300 && len(db.Executed()) > 0:
+					t.Errorf("refused request changed data: %v", db.Executed())
+				case want < 300 && tt.table != "" && len(db.ExecutedOn(tt.table)) == 0:
+					t.Errorf("%s wasn't changed", tt.table)
+				}
+			})
+		}
+	}
+}
+
+func TestGrantedMessageReads(t *testing.T) {
+	// A grant for messages, as a care team could get, opens the message routes
+	previous := accessGrants
+	t.Cleanup(func() { accessGrants = previous })
+	RegisterAccessGrant(func(actor models.User, subjectID uint, permission Permission) bool {
+		return actor.ID == strangerID && permission == PermissionReadMessages
+	})
+
+	for _, path := range []string{"/motivationalmessages/1", "/unreadmotivationalmessages/1", "/timedmotivationalmessages/1"} {
+		t.Run(path, func(t *testing.T) {
+			db := useFakeDB(t)
+			actorID(db, actorStranger)
+			patientData(db)
+
+			recorder := serve(authorizationRouter(strangerID), http.MethodGet, path, "")
+			if recorder.Code != http.StatusOK {
+				t.Errorf("GET %s = %d, want %d: %s", path, recorder.Code, http.StatusOK, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestGuardianReadDoesNotCreateDefaultGoal(t *testing.T) {
+	db := useFakeDB(t)
+	guardianLink(db, true)
+	noRows(db, "nutrition_goals")
+
+	recorder := serve(authorizationRouter(guardianID), http.MethodGet, "/getnutritiongoal/1", "")
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("GET /getnutritiongoal/1 = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+	if executed := db.Executed(); len(executed) > 0 {
+		t.Errorf("guardian read created data: %v", executed)
+	}
+}
+
+func TestOwnerGetsDefaultGoal(t *testing.T) {
+	db := useFakeDB(t)
+	db.On("users", []string{"id", "timezone"}, []driver.Value{int64(patientID), "UTC"})
+	noRows(db, "nutrition_goals", "daily_summaries", "goal_progression_policies", "body_metrics", "measurements")
+
+	recorder := serve(authorizationRouter(patientID), http.MethodGet, "/getnutritiongoal/1", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("GET /getnutritiongoal/1 = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+	}
+	if len(db.ExecutedOn("nutrition_goals")) == 0 {
+		t.Error("no default goal was created")
+	}
+}
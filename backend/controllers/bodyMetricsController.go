@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/energy"
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/measurements"
+	"BAZ/Nutritracker/models"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// findBodyMetrics returns the body metrics of a user, empty ones if they
+// never set them.
+func findBodyMetrics(userID uint) (models.BodyMetrics, error) {
+	metrics := models.BodyMetrics{UserID: userID}
+	err := initializers.DB.Where("user_id = ?", userID).First(&metrics).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return metrics, nil
+	}
+	return metrics, err
+}
+
+// suggestGoal computes a nutrition goal for a user from their body metrics.
+// The trend of their logged weight, when they log it, replaces the weight of
+// the metrics.
+func suggestGoal(userID uint, formula string, strategy string) (energy.Suggestion, error) {
+	metrics, err := findBodyMetrics(userID)
+	if err != nil {
+		return energy.Suggestion{}, err
+	}
+	weight, ok, err := measurements.CurrentTrend(initializers.DB, userID, models.MeasurementWeight)
+	if err != nil {
+		return energy.Suggestion{}, err
+	}
+	if ok {
+		metrics.WeightKg = weight
+	}
+	return energy.Suggest(metrics, models.DateOf(userNow(userID)), formula, strategy)
+}
+
+// GetBodyMetrics returns the body metrics of a user
+func GetBodyMetrics(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionReadMeasurements)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	metrics, err := findBodyMetrics(userID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to fetch body metrics"})
+		return
+	}
+
+	c.JSON(200, gin.H{"body_metrics": metrics})
+}
+
+// UpdateBodyMetrics sets the body metrics of a user, leaving out fields keeps them
+func UpdateBodyMetrics(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionWrite)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Sex           *string  `json:"sex"`
+		BirthDate     *string  `json:"birth_date"`
+		HeightCm      *float64 `json:"height_cm"`
+		WeightKg      *float64 `json:"weight_kg"`
+		ActivityLevel *string  `json:"activity_level"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	metrics, err := findBodyMetrics(userID)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to fetch body metrics"})
+		return
+	}
+
+	if body.Sex != nil {
+		if !models.IsValidSex(*body.Sex) {
+			c.JSON(400, gin.H{"error": "sex must be male or female"})
+			return
+		}
+		metrics.Sex = *body.Sex
+	}
+	if body.BirthDate != nil {
+		birthDate, err := models.ParseDate(*body.BirthDate)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid birth date, expected YYYY-MM-DD"})
+			return
+		}
+		if birthDate >= models.DateOf(userNow(userID)) {
+			c.JSON(400, gin.H{"error": "birth date must be in the past"})
+			return
+		}
+		metrics.BirthDate = birthDate
+	}
+	if body.HeightCm != nil {
+		if *body.HeightCm < 50 || *body.HeightCm > 272 {
+			c.JSON(400, gin.H{"error": "height must be between 50 and 272 cm"})
+			return
+		}
+		metrics.HeightCm = *body.HeightCm
+	}
+	if body.WeightKg != nil {
+		if *body.WeightKg < 20 || *body.WeightKg > 500 {
+			c.JSON(400, gin.H{"error": "weight must be between 20 and 500 kg"})
+			return
+		}
+		metrics.WeightKg = *body.WeightKg
+	}
+	if body.ActivityLevel != nil {
+		if !models.IsValidActivityLevel(*body.ActivityLevel) {
+			c.JSON(400, gin.H{"error": "activity level must be sedentary, light, moderate, active or very_active"})
+			return
+		}
+		metrics.ActivityLevel = *body.ActivityLevel
+	}
+
+	if err := initializers.DB.Save(&metrics).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update body metrics"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":      "Body metrics updated successfully",
+		"body_metrics": metrics,
+	})
+}
+
+// SuggestNutritionGoal computes a nutrition goal from the body metrics of a
+// user, with the formula and macro strategy of the query
+func SuggestNutritionGoal(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionReadMeasurements)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	suggestion, err := suggestGoal(userID, c.Query("formula"), c.Query("strategy"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"suggestion": suggestion})
+}
+
+// AcceptSuggestedGoal makes the suggested nutrition goal the active goal of a user
+func AcceptSuggestedGoal(c *gin.Context) {
+	var body struct {
+		UserID   uint   `json:"user_id"`
+		Formula  string `json:"formula"`
+		Strategy string `json:"strategy"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	// Defaults to the authenticated user, other users need an explicit grant
+	userID, ok := authorizeSubjectID(c, body.UserID, PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	// Computed again so the goal matches the current body metrics
+	suggestion, err := suggestGoal(userID, body.Formula, body.Strategy)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	nutritionGoal := suggestion.Goal(userID)
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceNutritionGoal(tx, &nutritionGoal, userNow(userID))
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create nutrition goal"})
+		return
+	}
+	refreshTodaySummary(userID)
+
+	c.JSON(200, gin.H{
+		"message":        "Nutrition goal created",
+		"nutrition_goal": nutritionGoal,
+		"suggestion":     suggestion,
+	})
+}
@@ -0,0 +1,484 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxEncouragementLength keeps buddy notes short.
+const maxEncouragementLength = 280
+
+// buddyProgress is what buddies can see of each other. It never includes meals.
+type buddyProgress struct {
+	UserID             uint                 `json:"user_id"`
+	Username           string               `json:"username"`
+	FirstName          string               `json:"first_name"`
+	Streak             int                  `json:"streak"`
+	AchievementsGained int                  `json:"achievements_gained"`
+	RecentAchievements []models.Achievement `json:"recent_achievements,omitempty"`
+}
+
+// buddyRequest is a pending request as listed to either side. It only shows
+// the public profile of the other user, never their contact details.
+type buddyRequest struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	FirstName string    `json:"first_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newBuddyRequest lists a relation with the profile of the other user.
+func newBuddyRequest(relation models.Buddy, other models.User) buddyRequest {
+	return buddyRequest{
+		ID:        relation.ID,
+		UserID:    other.ID,
+		Username:  other.Username,
+		FirstName: other.FirstName,
+		CreatedAt: relation.CreatedAt,
+	}
+}
+
+// findBuddyRelation returns the relationship between two users, in either direction.
+func findBuddyRelation(userID uint, otherID uint) (models.Buddy, error) {
+	var relation models.Buddy
+	err := initializers.DB.
+		Where("(requester_id = ? AND addressee_id = ?) OR (requester_id = ? AND addressee_id = ?)", userID, otherID, otherID, userID).
+		First(&relation).Error
+	return relation, err
+}
+
+// loadBuddyProgress collects the streak and achievements of a buddy.
+func loadBuddyProgress(user models.User, withRecent bool) buddyProgress {
+	progress := buddyProgress{
+		UserID:    user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+	}
+
+	var record models.Stats
+	if err := initializers.DB.Where("user_id = ?", user.ID).First(&record).Error; err == nil {
+		progress.Streak = stats.CurrentStreak(record, time.Now().In(user.Location()))
+		progress.AchievementsGained = record.Achievements_gained
+	}
+
+	if withRecent {
+		var earned []models.Accomplished_achievements
+		initializers.DB.Preload("Achievement").
+			Where("user_id = ?", user.ID).
+			Order("date_achieved DESC").
+			Limit(5).
+			Find(&earned)
+		for _, accomplished := range earned {
+			progress.RecentAchievements = append(progress.RecentAchievements, accomplished.Achievement)
+		}
+	}
+	return progress
+}
+
+// parseBuddyUserID reads the user ID of the other buddy from the path.
+func parseBuddyUserID(c *gin.Context) (uint, bool) {
+	otherID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return 0, false
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return 0, false
+	}
+	return uint(otherID), true
+}
+
+// SendBuddyRequest sends a buddy request to the user with the given username or
+// email. If that user already asked us, the request is accepted right away.
+func SendBuddyRequest(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var other models.User
+	var err error
+	switch {
+	case body.Email != "":
+		err = initializers.DB.Where("email = ?", strings.TrimSpace(body.Email)).First(&other).Error
+	case body.Username != "":
+		err = initializers.DB.Where("username = ?", strings.TrimSpace(body.Username)).First(&other).Error
+	default:
+		c.JSON(400, gin.H{"error": "Username or email is required"})
+		return
+	}
+	if err != nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	if other.ID == user.ID {
+		c.JSON(400, gin.H{"error": "You can't be your own buddy"})
+		return
+	}
+
+	relation, err := findBuddyRelation(user.ID, other.ID)
+	if err == nil {
+		switch {
+		case relation.Status == models.BuddyStatusBlocked:
+			// Don't reveal who blocked whom
+			c.JSON(http.StatusForbidden, gin.H{"error": "Unable to send buddy request"})
+		case relation.Status == models.BuddyStatusAccepted:
+			c.JSON(400, gin.H{"error": "You are already buddies"})
+		case relation.AddresseeID == user.ID:
+			// They already asked us, so this counts as accepting
+			if err := initializers.DB.Model(&relation).Update("status", models.BuddyStatusAccepted).Error; err != nil {
+				c.JSON(400, gin.H{"error": "Failed to accept buddy request"})
+				return
+			}
+			c.JSON(200, gin.H{"message": "Buddy request accepted", "buddy": relation})
+		default:
+			c.JSON(400, gin.H{"error": "Buddy request already sent"})
+		}
+		return
+	}
+
+	relation = models.Buddy{
+		RequesterID: user.ID,
+		AddresseeID: other.ID,
+		Status:      models.BuddyStatusPending,
+	}
+
+	if err := initializers.DB.Create(&relation).Error; err != nil {
+		// The other user may have asked us in the meantime
+		if _, err := findBuddyRelation(user.ID, other.ID); err == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "A buddy request between you already exists"})
+			return
+		}
+		c.JSON(400, gin.H{"error": "Failed to send buddy request"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Buddy request sent",
+		"buddy":   relation,
+	})
+}
+
+// GetBuddyRequests lists the pending buddy requests sent to and by the authenticated user
+func GetBuddyRequests(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var received []models.Buddy
+	initializers.DB.Preload("Requester").
+		Where("addressee_id = ? AND status = ?", user.ID, models.BuddyStatusPending).
+		Find(&received)
+
+	var sent []models.Buddy
+	initializers.DB.Preload("Addressee").
+		Where("requester_id = ? AND status = ?", user.ID, models.BuddyStatusPending).
+		Find(&sent)
+
+	incoming := make([]buddyRequest, 0, len(received))
+	for _, relation := range received {
+		incoming = append(incoming, newBuddyRequest(relation, relation.Requester))
+	}
+	outgoing := make([]buddyRequest, 0, len(sent))
+	for _, relation := range sent {
+		outgoing = append(outgoing, newBuddyRequest(relation, relation.Addressee))
+	}
+
+	c.JSON(200, gin.H{
+		"incoming": incoming,
+		"outgoing": outgoing,
+	})
+}
+
+// RespondToBuddyRequest accepts or declines a pending request sent to the authenticated user
+func RespondToBuddyRequest(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	var body struct {
+		Accept bool `json:"accept"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var relation models.Buddy
+	err := initializers.DB.
+		Where("id = ? AND addressee_id = ? AND status = ?", id, user.ID, models.BuddyStatusPending).
+		First(&relation).Error
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Buddy request not found"})
+		return
+	}
+
+	if !body.Accept {
+		// Declining removes the request so it can be sent again later
+		if err := initializers.DB.Unscoped().Delete(&relation).Error; err != nil {
+			c.JSON(400, gin.H{"error": "Failed to decline buddy request"})
+			return
+		}
+		c.JSON(200, gin.H{"message": "Buddy request declined"})
+		return
+	}
+
+	if err := initializers.DB.Model(&relation).Update("status", models.BuddyStatusAccepted).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to accept buddy request"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Buddy request accepted",
+		"buddy":   relation,
+	})
+}
+
+// GetBuddies lists the buddies of the authenticated user with their streaks and achievements
+func GetBuddies(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var relations []models.Buddy
+	result := initializers.DB.Preload("Requester").Preload("Addressee").
+		Where("(requester_id = ? OR addressee_id = ?) AND status = ?", user.ID, user.ID, models.BuddyStatusAccepted).
+		Find(&relations)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch buddies"})
+		return
+	}
+
+	buddies := make([]buddyProgress, 0, len(relations))
+	for _, relation := range relations {
+		other := relation.Requester
+		if other.ID == user.ID {
+			other = relation.Addressee
+		}
+		buddies = append(buddies, loadBuddyProgress(other, false))
+	}
+
+	c.JSON(200, gin.H{"buddies": buddies})
+}
+
+// GetBuddyProgress returns the streak and recent achievements of one buddy
+func GetBuddyProgress(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	otherID, ok := parseBuddyUserID(c)
+	if !ok {
+		return
+	}
+
+	relation, err := findBuddyRelation(user.ID, otherID)
+	if err != nil || relation.Status != models.BuddyStatusAccepted {
+		c.JSON(404, gin.H{"error": "Buddy not found"})
+		return
+	}
+
+	var other models.User
+	if err := initializers.DB.First(&other, otherID).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Buddy not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"buddy": loadBuddyProgress(other, true)})
+}
+
+// RemoveBuddy ends a buddy relationship or withdraws a pending request
+func RemoveBuddy(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	otherID, ok := parseBuddyUserID(c)
+	if !ok {
+		return
+	}
+
+	relation, err := findBuddyRelation(user.ID, otherID)
+	if err != nil || relation.Status == models.BuddyStatusBlocked {
+		c.JSON(404, gin.H{"error": "Buddy not found"})
+		return
+	}
+
+	if err := initializers.DB.Unscoped().Delete(&relation).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to remove buddy"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Buddy removed successfully"})
+}
+
+// BlockUser blocks another user, ending any buddy relationship with them and
+// preventing new requests and encouragements in both directions
+func BlockUser(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	otherID, ok := parseBuddyUserID(c)
+	if !ok {
+		return
+	}
+
+	if otherID == user.ID {
+		c.JSON(400, gin.H{"error": "You can't block yourself"})
+		return
+	}
+
+	relation, err := findBuddyRelation(user.ID, otherID)
+	if err != nil {
+		var other models.User
+		if err := initializers.DB.First(&other, otherID).Error; err != nil {
+			c.JSON(404, gin.H{"error": "User not found"})
+			return
+		}
+		relation = models.Buddy{RequesterID: user.ID, AddresseeID: other.ID}
+	} else if relation.Status == models.BuddyStatusBlocked {
+		c.JSON(400, gin.H{"error": "User is already blocked"})
+		return
+	}
+
+	relation.Status = models.BuddyStatusBlocked
+	relation.BlockedByID = &user.ID
+
+	if err := initializers.DB.Save(&relation).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to block user"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User blocked"})
+}
+
+// UnblockUser lifts a block the authenticated user placed
+func UnblockUser(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	otherID, ok := parseBuddyUserID(c)
+	if !ok {
+		return
+	}
+
+	relation, err := findBuddyRelation(user.ID, otherID)
+	if err != nil || relation.Status != models.BuddyStatusBlocked || relation.BlockedByID == nil || *relation.BlockedByID != user.ID {
+		c.JSON(404, gin.H{"error": "Block not found"})
+		return
+	}
+
+	if err := initializers.DB.Unscoped().Delete(&relation).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "User unblocked"})
+}
+
+// SendEncouragement sends a short note to a buddy. It shows up in their
+// motivational message feed.
+func SendEncouragement(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	otherID, ok := parseBuddyUserID(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	body.Message = strings.TrimSpace(body.Message)
+	if body.Message == "" || len([]rune(body.Message)) > maxEncouragementLength {
+		c.JSON(400, gin.H{"error": "Message must be between 1 and 280 characters"})
+		return
+	}
+
+	relation, err := findBuddyRelation(user.ID, otherID)
+	if err != nil || relation.Status != models.BuddyStatusAccepted {
+		c.JSON(404, gin.H{"error": "Buddy not found"})
+		return
+	}
+
+	message := models.MotivationalMessage{
+		Message:     body.Message,
+		MessageType: "buddy",
+		UserID:      otherID,
+		IsRead:      false,
+		SenderID:    &user.ID,
+	}
+
+	if err := initializers.DB.Create(&message).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to send encouragement"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":              "Encouragement sent",
+		"motivational_message": message,
+	})
+}
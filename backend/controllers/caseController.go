@@ -0,0 +1,353 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errCaseChanged = errors.New("case was changed concurrently")
+
+// GetCases lists cases, filtered by the status, user_id, assignee_id and rule
+// query parameters. assignee_id=me returns the cases assigned to the caller.
+func GetCases(c *gin.Context) {
+	clinician, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Preload("User").Preload("Assignee").Order("created_at DESC")
+
+	if status := c.Query("status"); status != "" {
+		if status == "active" {
+			query = query.Where("status IN ?", models.ActiveCaseStatuses)
+		} else {
+			query = query.Where("status = ?", status)
+		}
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	switch assigneeID := c.Query("assignee_id"); assigneeID {
+	case "":
+	case "me":
+		query = query.Where("assignee_id = ?", clinician.ID)
+	case "none":
+		query = query.Where("assignee_id IS NULL")
+	default:
+		query = query.Where("assignee_id = ?", assigneeID)
+	}
+	if rule := c.Query("rule"); rule != "" {
+		query = query.Where("rule LIKE ?", rule+"%")
+	}
+
+	var cases []models.Cases
+	if err := query.Find(&cases).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch cases"})
+		return
+	}
+
+	c.JSON(200, gin.H{"cases": cases})
+}
+
+// GetCase returns a case together with its audit trail
+func GetCase(c *gin.Context) {
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var found models.Cases
+	result := initializers.DB.
+		Preload("User").
+		Preload("Assignee").
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Events.Actor").
+		First(&found, id)
+
+	if result.Error != nil {
+		c.JSON(404, gin.H{"error": "Case not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"case": found})
+}
+
+// CreateCase opens a case for a patient by hand
+func CreateCase(c *gin.Context) {
+	clinician, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		UserID uint   `json:"user_id"`
+		Reason string `json:"reason"`
+		Note   string `json:"notes"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if body.Reason == "" {
+		c.JSON(400, gin.H{"error": "Reason is required"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var patient models.User
+	if err := initializers.DB.Where("id = ? AND role = ?", body.UserID, models.RolePatient).First(&patient).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	newCase := models.Cases{
+		Status: models.CaseStatusOpen,
+		Reason: body.Reason,
+		Note:   body.Note,
+		UserID: patient.ID,
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newCase).Error; err != nil {
+			return err
+		}
+		if err := recordCaseEvent(tx, newCase.ID, clinician.ID, models.CaseEvent{
+			Type:     models.CaseEventCreated,
+			ToStatus: newCase.Status,
+			Comment:  body.Reason,
+		}); err != nil {
+			return err
+		}
+		return stats.RefreshCases(tx, patient.ID)
+	})
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create case"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Case created",
+		"case":    newCase,
+	})
+}
+
+// AssignCase assigns a case to a clinician. Without an assignee_id the case is
+// assigned to the caller, with assignee_id 0 it is unassigned.
+func AssignCase(c *gin.Context) {
+	clinician, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	var body struct {
+		AssigneeID *uint `json:"assignee_id"`
+	}
+	// The body is optional, without one the case goes to the caller
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var found models.Cases
+	if err := initializers.DB.First(&found, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Case not found"})
+		return
+	}
+
+	var assigneeID *uint
+	switch {
+	case body.AssigneeID == nil:
+		assigneeID = &clinician.ID
+	case *body.AssigneeID != 0:
+		var assignee models.User
+		err := initializers.DB.
+			Where("id = ? AND role IN ?", *body.AssigneeID, []string{models.RoleClinician, models.RoleAdmin}).
+			First(&assignee).Error
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Cases can only be assigned to clinicians"})
+			return
+		}
+		assigneeID = &assignee.ID
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&found).Update("assignee_id", assigneeID).Error; err != nil {
+			return err
+		}
+		return recordCaseEvent(tx, found.ID, clinician.ID, models.CaseEvent{
+			Type:       models.CaseEventAssigned,
+			AssigneeID: assigneeID,
+		})
+	})
+
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to assign case"})
+		return
+	}
+
+	found.AssigneeID = assigneeID
+
+	c.JSON(200, gin.H{
+		"message": "Case assigned successfully",
+		"case":    found,
+	})
+}
+
+// AddCaseComment adds a comment to the audit trail of a case
+func AddCaseComment(c *gin.Context) {
+	clinician, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	var body struct {
+		Comment string `json:"comment"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if body.Comment == "" {
+		c.JSON(400, gin.H{"error": "Comment is required"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var found models.Cases
+	if err := initializers.DB.First(&found, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Case not found"})
+		return
+	}
+
+	if err := recordCaseEvent(initializers.DB, found.ID, clinician.ID, models.CaseEvent{
+		Type:    models.CaseEventComment,
+		Comment: body.Comment,
+	}); err != nil {
+		c.JSON(400, gin.H{"error": "Failed to add comment"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Comment added"})
+}
+
+// UpdateCaseStatus moves a case to a new status if the workflow allows it
+func UpdateCaseStatus(c *gin.Context) {
+	clinician, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	var body struct {
+		Status  string `json:"status"`
+		Comment string `json:"comment"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if !models.IsValidCaseStatus(body.Status) {
+		c.JSON(400, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var found models.Cases
+	if err := initializers.DB.First(&found, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Case not found"})
+		return
+	}
+
+	fromStatus := found.Status
+	if !models.CanTransitionCase(fromStatus, body.Status) {
+		c.JSON(400, gin.H{"error": "Cannot move a case from " + fromStatus + " to " + body.Status})
+		return
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Only update if nobody changed the status in the meantime
+		result := tx.Model(&models.Cases{}).
+			Where("id = ? AND status = ?", found.ID, fromStatus).
+			Update("status", body.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCaseChanged
+		}
+		if err := recordCaseEvent(tx, found.ID, clinician.ID, models.CaseEvent{
+			Type:       models.CaseEventStatusChanged,
+			FromStatus: fromStatus,
+			ToStatus:   body.Status,
+			Comment:    body.Comment,
+		}); err != nil {
+			return err
+		}
+		return stats.RefreshCases(tx, found.UserID)
+	})
+
+	if err == errCaseChanged {
+		c.JSON(http.StatusConflict, gin.H{"error": "Case was changed by someone else, please reload"})
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update case status"})
+		return
+	}
+
+	found.Status = body.Status
+	c.JSON(200, gin.H{
+		"message": "Case status updated successfully",
+		"case":    found,
+	})
+}
+
+// recordCaseEvent adds an entry made by actorID to the audit trail of a case.
+func recordCaseEvent(tx *gorm.DB, caseID uint, actorID uint, event models.CaseEvent) error {
+	event.CaseID = caseID
+	event.ActorID = &actorID
+	return tx.Create(&event).Error
+}
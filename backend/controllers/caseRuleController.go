@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCaseRuleSettings returns the case rule thresholds of a patient, or the defaults if none are set
+func GetCaseRuleSettings(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	settings := models.DefaultCaseRuleSettings(uint(userID))
+	initializers.DB.Where("user_id = ?", uint(userID)).First(&settings)
+
+	c.JSON(200, gin.H{"case_rule_settings": settings})
+}
+
+// UpdateCaseRuleSettings sets the case rule thresholds of a patient
+func UpdateCaseRuleSettings(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Enabled               *bool `json:"enabled"`
+		MissedMealDays        *int  `json:"missed_meal_days"`
+		LowCaloriesPercentage *int  `json:"low_calories_percentage"`
+		LowCaloriesDays       *int  `json:"low_calories_days"`
+		WeightLossPercentage  *int  `json:"weight_loss_percentage"`
+		WeightLossDays        *int  `json:"weight_loss_days"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var patient models.User
+	if err := initializers.DB.Where("id = ? AND role = ?", uint(userID), models.RolePatient).First(&patient).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	settings := models.DefaultCaseRuleSettings(patient.ID)
+	initializers.DB.Where("user_id = ?", patient.ID).First(&settings)
+
+	if body.Enabled != nil {
+		settings.Enabled = *body.Enabled
+	}
+	if body.MissedMealDays != nil {
+		settings.MissedMealDays = *body.MissedMealDays
+	}
+	if body.LowCaloriesPercentage != nil {
+		settings.LowCaloriesPercentage = *body.LowCaloriesPercentage
+	}
+	if body.LowCaloriesDays != nil {
+		settings.LowCaloriesDays = *body.LowCaloriesDays
+	}
+	if body.WeightLossPercentage != nil {
+		settings.WeightLossPercentage = *body.WeightLossPercentage
+	}
+	if body.WeightLossDays != nil {
+		settings.WeightLossDays = *body.WeightLossDays
+	}
+
+	if settings.MissedMealDays < 0 || settings.LowCaloriesDays < 0 || settings.LowCaloriesPercentage < 0 || settings.LowCaloriesPercentage > 100 ||
+		settings.WeightLossDays < 0 || settings.WeightLossPercentage < 0 || settings.WeightLossPercentage > 100 {
+		c.JSON(400, gin.H{"error": "Invalid thresholds"})
+		return
+	}
+
+	if err := initializers.DB.Save(&settings).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update case rule settings"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":            "Case rule settings updated successfully",
+		"case_rule_settings": settings,
+	})
+}
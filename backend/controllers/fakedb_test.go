@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/internal/fakedb"
+	"testing"
+)
+
+// useFakeDB points initializers.DB at a new fake database for one test. The
+// test fails if a handler ran a query the test didn't answer.
+func useFakeDB(t *testing.T) *fakedb.DB {
+	t.Helper()
+	db, gormDB, err := fakedb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous := initializers.DB
+	initializers.DB = gormDB
+	t.Cleanup(func() {
+		initializers.DB = previous
+		for _, query := range db.Unanswered() {
+			t.Errorf("unanswered query: %s", query.SQL)
+		}
+	})
+	return db
+}
@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/barcode"
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultFoodSearchLimit = 20
+	maxFoodSearchLimit     = 100
+)
+
+// foodPortion is how much of a catalog food a nutrilog refers to: either a
+// quantity in g/ml, or a number of servings. Barcode is the code the food was
+// scanned with, if it was.
+type foodPortion struct {
+	FoodID    uint    `json:"food_id"`
+	Quantity  float64 `json:"quantity"`
+	ServingID uint    `json:"serving_id"`
+	Servings  float64 `json:"servings"`
+	Barcode   string  `json:"barcode"`
+}
+
+// applyFoodPortion fills in the nutrients of a nutrilog from the food catalog.
+func applyFoodPortion(nutrilog *models.Nutrilog, portion foodPortion) error {
+	var food models.Food
+	if err := initializers.DB.Preload("Servings").First(&food, portion.FoodID).Error; err != nil {
+		return errors.New("food not found")
+	}
+	if portion.Barcode != "" && (food.Barcode == nil || *food.Barcode != portion.Barcode) {
+		return errors.New("the food doesn't have this barcode")
+	}
+
+	quantity := portion.Quantity
+	if portion.ServingID != 0 {
+		servings := portion.Servings
+		if servings == 0 {
+			servings = 1
+		}
+		found := false
+		for _, serving := range food.Servings {
+			if serving.ID == portion.ServingID {
+				quantity = serving.Quantity * servings
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.New("serving not found for this food")
+		}
+	}
+
+	if quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	amounts := food.NutrientsFor(quantity)
+	nutrilog.Calories, nutrilog.Proteins, nutrilog.Fats, nutrilog.Carbohydrates = amounts.Rounded()
+	nutrilog.Nutrients = amounts.Nutrients.Rounded()
+	nutrilog.FoodID = &food.ID
+	nutrilog.Quantity = quantity
+	if portion.Barcode != "" {
+		nutrilog.Source = models.NutrilogSourceBarcode
+	}
+	if nutrilog.MealDescription == "" {
+		nutrilog.MealDescription = food.Name
+		if food.Brand != "" {
+			nutrilog.MealDescription += " (" + food.Brand + ")"
+		}
+	}
+	return nil
+}
+
+// SearchFoods searches the food catalog by name or brand
+func SearchFoods(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+
+	limit := defaultFoodSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(400, gin.H{"error": "Invalid limit"})
+			return
+		}
+		if parsed < maxFoodSearchLimit {
+			limit = parsed
+		} else {
+			limit = maxFoodSearchLimit
+		}
+	}
+
+	if q == "" {
+		c.JSON(400, gin.H{"error": "Search query is required"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var foods []models.Food
+	pattern := "%" + q + "%"
+	result := initializers.DB.Preload("Servings").
+		Where("name LIKE ? OR brand LIKE ? OR barcode = ?", pattern, pattern, q).
+		// Names starting with the query first, then shortest names as they are the closest match
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN name LIKE ? THEN 0 ELSE 1 END, LENGTH(name)",
+			Vars:               []interface{}{q + "%"},
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Find(&foods)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to search foods"})
+		return
+	}
+
+	c.JSON(200, gin.H{"foods": foods})
+}
+
+// GetFood returns a single food with its serving sizes
+func GetFood(c *gin.Context) {
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var food models.Food
+	if err := initializers.DB.Preload("Servings").First(&food, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Food not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"food": food})
+}
+
+// CreateFood adds a food to the catalog
+func CreateFood(c *gin.Context) {
+	createFood(c, "")
+}
+
+// createFood adds a food from the request body. A non-empty code overrides the
+// barcode in the body.
+func createFood(c *gin.Context, code string) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Name                string                 `json:"name"`
+		Brand               string                 `json:"brand"`
+		Barcode             string                 `json:"barcode"`
+		Unit                string                 `json:"unit"`
+		CaloriesPer100      float64                `json:"calories_per_100"`
+		ProteinsPer100      float64                `json:"proteins_per_100"`
+		FatsPer100          float64                `json:"fats_per_100"`
+		CarbohydratesPer100 float64                `json:"carbohydrates_per_100"`
+		NutrientsPer100     models.NutrientAmounts `json:"nutrients_per_100"`
+		Servings            []struct {
+			Name     string  `json:"name"`
+			Quantity float64 `json:"quantity"`
+		} `json:"servings"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		c.JSON(400, gin.H{"error": "Name is required"})
+		return
+	}
+
+	if body.Unit == "" {
+		body.Unit = models.FoodUnitGram
+	}
+	if body.Unit != models.FoodUnitGram && body.Unit != models.FoodUnitMilliliter {
+		c.JSON(400, gin.H{"error": "Unit must be g or ml"})
+		return
+	}
+
+	if body.CaloriesPer100 < 0 || body.ProteinsPer100 < 0 || body.FatsPer100 < 0 || body.CarbohydratesPer100 < 0 {
+		c.JSON(400, gin.H{"error": "Nutrient values can't be negative"})
+		return
+	}
+	if err := nutrients.Validate(body.NutrientsPer100); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	food := models.Food{
+		Name:                body.Name,
+		Brand:               strings.TrimSpace(body.Brand),
+		Unit:                body.Unit,
+		CaloriesPer100:      body.CaloriesPer100,
+		ProteinsPer100:      body.ProteinsPer100,
+		FatsPer100:          body.FatsPer100,
+		CarbohydratesPer100: body.CarbohydratesPer100,
+		NutrientsPer100:     body.NutrientsPer100.Rounded(),
+		CreatedByID:         &user.ID,
+	}
+	if code == "" {
+		code = strings.TrimSpace(body.Barcode)
+	}
+	if code != "" {
+		food.Barcode = &code
+	}
+	for _, serving := range body.Servings {
+		if serving.Name == "" || serving.Quantity <= 0 {
+			c.JSON(400, gin.H{"error": "Servings need a name and a positive quantity"})
+			return
+		}
+		food.Servings = append(food.Servings, models.FoodServing{Name: serving.Name, Quantity: serving.Quantity})
+	}
+
+	if err := initializers.DB.Create(&food).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create food, the barcode may already exist"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message": "Food created",
+		"food":    food,
+	})
+}
+
+// barcodeService looks up barcodes in the local catalog and the configured providers.
+func barcodeService() barcode.Service {
+	return barcode.Service{DB: initializers.DB, Providers: barcode.ProvidersFromEnv()}
+}
+
+// LookupBarcode returns the food for a scanned barcode. Unknown products can be
+// submitted with SubmitBarcodeProduct.
+func LookupBarcode(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+
+	if !barcode.ValidBarcode(code) {
+		c.JSON(400, gin.H{"error": "Invalid barcode"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	food, provider, err := barcodeService().Lookup(c.Request.Context(), code)
+	if errors.Is(err, barcode.ErrNotFound) {
+		c.JSON(404, gin.H{
+			"error":      "Product not found",
+			"barcode":    code,
+			"can_submit": true,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to look up barcode"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"food":     food,
+		"provider": provider,
+	})
+}
+
+// SubmitBarcodeProduct adds a product that no provider knew to the catalog
+func SubmitBarcodeProduct(c *gin.Context) {
+	code := strings.TrimSpace(c.Param("code"))
+
+	if !barcode.ValidBarcode(code) {
+		c.JSON(400, gin.H{"error": "Invalid barcode"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var existing int64
+	initializers.DB.Model(&models.Food{}).Where("barcode = ?", code).Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "A product with this barcode already exists"})
+		return
+	}
+
+	createFood(c, code)
+	if c.Writer.Status() == http.StatusOK {
+		barcodeService().ForgetMiss(code)
+	}
+}
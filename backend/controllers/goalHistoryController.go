@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// replaceNutritionGoal makes goal the active version of the goals of its user
+// from today, now being the user's time. The active version is kept as it was
+// and ends today.
+func replaceNutritionGoal(tx *gorm.DB, goal *models.NutritionGoal, now time.Time) error {
+	today := models.DateOf(now)
+
+	var current []models.NutritionGoal
+	err := tx.Where("user_id = ? AND is_active = ?", goal.UserID, true).Order("id").Find(&current).Error
+	if err != nil {
+		return err
+	}
+
+	goal.Model = gorm.Model{}
+	goal.PreviousID = nil
+	for _, version := range current {
+		err := tx.Model(&models.NutritionGoal{}).Where("id = ?", version.ID).Updates(map[string]interface{}{
+			"is_active":    false,
+			"effective_to": today,
+		}).Error
+		if err != nil {
+			return err
+		}
+		previousID := version.ID
+		goal.PreviousID = &previousID
+	}
+
+	goal.IsActive = true
+	goal.StartDate = now
+	goal.EffectiveFrom = today
+	goal.EffectiveTo = nil
+	return tx.Create(goal).Error
+}
+
+// goalVersion is a version of a nutrition goal with how well it was kept on
+// the days it was in effect.
+type goalVersion struct {
+	models.NutritionGoal
+	DaysLogged       int      `json:"days_logged"`
+	GoalDaysAchieved int      `json:"goal_days_achieved"`
+	GoalAdherence    *float64 `json:"goal_adherence"`
+}
+
+// GetGoalHistory lists every version of the nutrition goals of a user, newest
+// first, optionally only those in effect between from and to
+func GetGoalHistory(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionReadGoals)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Where("user_id = ?", userID)
+	if raw := c.Query("from"); raw != "" {
+		from, err := models.ParseDate(raw)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("effective_to IS NULL OR effective_to >= ?", from)
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := models.ParseDate(raw)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+		query = query.Where("effective_from <= ?", to)
+	}
+
+	var goals []models.NutritionGoal
+	if err := query.Order("effective_from DESC, id DESC").Find(&goals).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch goal history"})
+		return
+	}
+
+	// Adherence of each version, from the summaries of the days it applied to
+	ids := make([]uint, len(goals))
+	for i, goal := range goals {
+		ids[i] = goal.ID
+	}
+	var rows []struct {
+		NutritionGoalID  uint
+		DaysLogged       int
+		GoalDaysAchieved int
+	}
+	err := initializers.DB.Model(&models.DailySummary{}).
+		Select("nutrition_goal_id, COUNT(*) AS days_logged, SUM(CASE WHEN goal_achieved THEN 1 ELSE 0 END) AS goal_days_achieved").
+		Where("user_id = ? AND nutrition_goal_id IN ?", userID, ids).
+		Group("nutrition_goal_id").
+		Scan(&rows).Error
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch goal history"})
+		return
+	}
+	achieved := make(map[uint]int, len(rows))
+	logged := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		logged[row.NutritionGoalID] = row.DaysLogged
+		achieved[row.NutritionGoalID] = row.GoalDaysAchieved
+	}
+
+	versions := make([]goalVersion, len(goals))
+	for i, goal := range goals {
+		versions[i] = goalVersion{
+			NutritionGoal:    goal,
+			DaysLogged:       logged[goal.ID],
+			GoalDaysAchieved: achieved[goal.ID],
+		}
+		if logged[goal.ID] > 0 {
+			share := math.Round(float64(achieved[goal.ID])/float64(logged[goal.ID])*100) / 100
+			versions[i].GoalAdherence = &share
+		}
+	}
+
+	c.JSON(200, gin.H{"goal_history": versions})
+}
@@ -0,0 +1,404 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/progression"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// goalProgressionPolicy returns the progression policy of a user, or the
+// default policy if none is set.
+func goalProgressionPolicy(userID uint) models.GoalProgressionPolicy {
+	policy := models.DefaultGoalProgressionPolicy(userID)
+	initializers.DB.Where("user_id = ?", userID).First(&policy)
+	return policy
+}
+
+// progressGoal applies the progression policy to a goal that was just reached.
+// A step is recorded as a goal change with its reason and, if the policy asks
+// for it, waits for a guardian to approve it. An applied step replaces goal
+// with its new version. Returns nil when the goal stays as it is.
+func progressGoal(tx *gorm.DB, goal *models.NutritionGoal, policy models.GoalProgressionPolicy, now time.Time) (*models.GoalChange, error) {
+	// Don't propose a new step while the previous one waits for approval
+	var pending int64
+	err := tx.Model(&models.GoalChange{}).
+		Where("nutrition_goal_id = ? AND status = ?", goal.ID, models.GoalChangePending).
+		Count(&pending).Error
+	if err != nil || pending > 0 {
+		return nil, err
+	}
+
+	decision := progression.Evaluate(policy, *goal, goal.GoalAchievedDays)
+	if !decision.Changes() {
+		return nil, nil
+	}
+
+	change := models.GoalChange{
+		UserID:          goal.UserID,
+		NutritionGoalID: goal.ID,
+		Status:          models.GoalChangeApplied,
+		Adjustments:     decision.Adjustments,
+		Reason:          decision.Reason,
+		Streak:          goal.GoalAchievedDays,
+	}
+	if policy.ID != 0 {
+		change.PolicyID = &policy.ID
+	}
+	if decision.NeedsApproval {
+		change.Status = models.GoalChangePending
+	}
+
+	if err := tx.Create(&change).Error; err != nil {
+		return nil, err
+	}
+	if decision.NeedsApproval {
+		// The streak starts over while the step waits, the caller saves the goal
+		goal.GoalAchievedDays = 0
+		if err := notifyGoalChangeReviewers(tx, change); err != nil {
+			return nil, err
+		}
+		return &change, nil
+	}
+
+	// The current version keeps the streak that led to the step
+	if err := tx.Save(goal).Error; err != nil {
+		return nil, err
+	}
+	if err := applyGoalChange(tx, goal, change, now); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// applyGoalChange replaces goal with a new version that has the adjustments
+// of the change.
+func applyGoalChange(tx *gorm.DB, goal *models.NutritionGoal, change models.GoalChange, now time.Time) error {
+	next := *goal
+	progression.Apply(&next, change.Adjustments)
+	next.GoalAchievedDays = 0
+	next.GoalChangeID = &change.ID
+	if err := replaceNutritionGoal(tx, &next, now); err != nil {
+		return err
+	}
+	*goal = next
+	return nil
+}
+
+// notifyGoalChangeReviewers tells the guardians the user shares goals with
+// that a goal change waits for their approval.
+func notifyGoalChangeReviewers(tx *gorm.DB, change models.GoalChange) error {
+	var patient models.User
+	if err := tx.First(&patient, change.UserID).Error; err != nil {
+		return err
+	}
+
+	var links []models.Guardian
+	if err := tx.Where("patient_id = ? AND share_goals = ? AND revoked_at IS NULL", change.UserID, true).Find(&links).Error; err != nil {
+		return err
+	}
+
+	for _, link := range links {
+		notification := models.Notification{
+			UserID:       link.GuardianID,
+			Type:         "goal_change_pending",
+			Message:      fmt.Sprintf("%s has a goal change waiting for your approval: %s", patient.FirstName, change.Reason),
+			GoalChangeID: &change.ID,
+		}
+		if err := tx.Create(&notification).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetGoalProgressionPolicy returns the progression policy of a user, or the defaults if none is set
+func GetGoalProgressionPolicy(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	c.JSON(200, gin.H{"goal_progression_policy": goalProgressionPolicy(uint(userID))})
+}
+
+// UpdateGoalProgressionPolicy sets how the goals of a user change once they keep reaching them
+func UpdateGoalProgressionPolicy(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var body struct {
+		Enabled         *bool                  `json:"enabled"`
+		RequiredStreak  *int                   `json:"required_streak"`
+		Tolerance       *float64               `json:"tolerance"`
+		Steps           models.NutrientAmounts `json:"steps"`
+		Ceilings        models.NutrientAmounts `json:"ceilings"`
+		RequireApproval *bool                  `json:"require_approval"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, uint(userID)).Error; err != nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	policy := goalProgressionPolicy(user.ID)
+
+	if body.Enabled != nil {
+		policy.Enabled = *body.Enabled
+	}
+	if body.RequiredStreak != nil {
+		policy.RequiredStreak = *body.RequiredStreak
+	}
+	if body.Tolerance != nil {
+		policy.Tolerance = *body.Tolerance
+	}
+	if body.Steps != nil {
+		policy.Steps = body.Steps
+	}
+	if body.Ceilings != nil {
+		policy.Ceilings = body.Ceilings
+	}
+	if body.RequireApproval != nil {
+		policy.RequireApproval = *body.RequireApproval
+	}
+
+	if err := progression.Validate(policy); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Without a reviewer an approval-required step would block progression for good
+	if policy.Enabled && policy.RequireApproval {
+		var reviewers int64
+		err := initializers.DB.Model(&models.Guardian{}).
+			Where("patient_id = ? AND share_goals = ? AND revoked_at IS NULL", user.ID, true).
+			Count(&reviewers).Error
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Failed to update goal progression policy"})
+			return
+		}
+		if reviewers == 0 {
+			c.JSON(400, gin.H{"error": "require_approval needs a guardian the user shares their goals with"})
+			return
+		}
+	}
+
+	if err := initializers.DB.Save(&policy).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update goal progression policy"})
+		return
+	}
+	refreshTodaySummary(user.ID)
+
+	c.JSON(200, gin.H{
+		"message":                 "Goal progression policy updated successfully",
+		"goal_progression_policy": policy,
+	})
+}
+
+// GetGoalChanges lists the automatic changes of the goals of a user, newest first
+func GetGoalChanges(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionReadGoals)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var changes []models.GoalChange
+	if err := initializers.DB.Where("user_id = ?", userID).Order("id DESC").Find(&changes).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch goal changes"})
+		return
+	}
+
+	c.JSON(200, gin.H{"goal_changes": changes})
+}
+
+// GetPatientGoalChanges lists the goal changes of a patient that shares their
+// goals, optionally only those with a status such as pending
+func GetPatientGoalChanges(c *gin.Context) {
+	link, ok := guardianPatientLink(c)
+	if !ok {
+		return
+	}
+
+	if !link.ShareGoals {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This patient does not share their goals"})
+		return
+	}
+
+	query := initializers.DB.Where("user_id = ?", link.PatientID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.GoalChange
+	if err := query.Order("id DESC").Find(&changes).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch goal changes"})
+		return
+	}
+
+	c.JSON(200, gin.H{"goal_changes": changes})
+}
+
+// ApprovePatientGoalChange applies a pending goal change of a patient
+func ApprovePatientGoalChange(c *gin.Context) {
+	reviewPatientGoalChange(c, true)
+}
+
+// RejectPatientGoalChange turns down a pending goal change of a patient
+func RejectPatientGoalChange(c *gin.Context) {
+	reviewPatientGoalChange(c, false)
+}
+
+// GetUserGoalChanges lists the goal changes of a user for their clinician,
+// optionally only those with a status such as pending
+func GetUserGoalChanges(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Where("user_id = ?", userID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.GoalChange
+	if err := query.Order("id DESC").Find(&changes).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch goal changes"})
+		return
+	}
+
+	c.JSON(200, gin.H{"goal_changes": changes})
+}
+
+// ApproveGoalChange lets a clinician apply a pending goal change, for
+// example when the guardian who would review it revoked their access
+func ApproveGoalChange(c *gin.Context) {
+	reviewUserGoalChange(c, true)
+}
+
+// RejectGoalChange lets a clinician turn down a pending goal change
+func RejectGoalChange(c *gin.Context) {
+	reviewUserGoalChange(c, false)
+}
+
+var errGoalReplaced = errors.New("goal replaced")
+
+func reviewPatientGoalChange(c *gin.Context, approve bool) {
+	link, ok := guardianPatientLink(c)
+	if !ok {
+		return
+	}
+
+	if !link.ShareGoals {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This patient does not share their goals"})
+		return
+	}
+
+	reviewGoalChange(c, link.PatientID, link.GuardianID, approve)
+}
+
+func reviewUserGoalChange(c *gin.Context, approve bool) {
+	clinician, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	reviewGoalChange(c, uint(userID), clinician.ID, approve)
+}
+
+// reviewGoalChange applies or rejects a pending goal change of a user on
+// behalf of reviewerID.
+func reviewGoalChange(c *gin.Context, userID uint, reviewerID uint, approve bool) {
+	var change models.GoalChange
+	err := initializers.DB.
+		Where("id = ? AND user_id = ? AND status = ?", c.Param("id"), userID, models.GoalChangePending).
+		First(&change).Error
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Pending goal change not found"})
+		return
+	}
+
+	now := userNow(userID)
+	change.Status = models.GoalChangeRejected
+	change.ReviewedByID = &reviewerID
+	change.ReviewedAt = &now
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if approve {
+			var goal models.NutritionGoal
+			if err := tx.First(&goal, change.NutritionGoalID).Error; err != nil || !goal.IsActive {
+				return errGoalReplaced
+			}
+			if err := applyGoalChange(tx, &goal, change, now); err != nil {
+				return err
+			}
+			change.Status = models.GoalChangeApplied
+		}
+		return tx.Save(&change).Error
+	})
+	if errors.Is(err, errGoalReplaced) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The goal was replaced since this change was proposed"})
+		return
+	}
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to review goal change"})
+		return
+	}
+	if approve {
+		refreshTodaySummary(userID)
+	}
+
+	c.JSON(200, gin.H{
+		"message":     "Goal change " + change.Status,
+		"goal_change": change,
+	})
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/helpers"
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	invitationCodeLength       = 8
+	defaultInvitationValidity  = 48 * time.Hour
+	maxInvitationValidityHours = 7 * 24
+)
+
+func init() {
+	RegisterAccessGrant(guardianAccessGrant)
+}
+
+// guardianAccessGrant gives linked guardians read-only access to their
+// patients, limited to what the patient shares with them.
+func guardianAccessGrant(actor models.User, subjectID uint, permission Permission) bool {
+	if initializers.DB == nil {
+		return false
+	}
+	link, err := activeGuardianLink(subjectID, actor.ID)
+	if err != nil {
+		return false
+	}
+
+	switch permission {
+	case PermissionRead:
+		return true
+	case PermissionReadGoals:
+		return link.ShareGoals
+	case PermissionReadMeals:
+		return link.ShareMeals && link.ShareMealDescriptions
+	case PermissionReadMeasurements:
+		return link.ShareMeasurements
+	}
+	return false
+}
+
+// activeGuardianLink returns the unrevoked link between a patient and a guardian.
+func activeGuardianLink(patientID uint, guardianID uint) (models.Guardian, error) {
+	var link models.Guardian
+	err := initializers.DB.
+		Where("patient_id = ? AND guardian_id = ? AND revoked_at IS NULL", patientID, guardianID).
+		First(&link).Error
+	return link, err
+}
+
+// CreateGuardianInvitation creates a time-limited code the patient can share with a guardian
+func CreateGuardianInvitation(c *gin.Context) {
+	patient, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		ExpiresInHours int `json:"expires_in_hours"`
+	}
+	c.ShouldBindJSON(&body)
+
+	validity := defaultInvitationValidity
+	if body.ExpiresInHours > 0 {
+		if body.ExpiresInHours > maxInvitationValidityHours {
+			c.JSON(400, gin.H{"error": "Invitations can be valid for at most 7 days"})
+			return
+		}
+		validity = time.Duration(body.ExpiresInHours) * time.Hour
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	code, err := helpers.NewInvitationCode(invitationCodeLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate invitation code"})
+		return
+	}
+
+	invitation := models.GuardianInvitation{
+		Code:      code,
+		PatientID: patient.ID,
+		ExpiresAt: time.Now().Add(validity),
+	}
+
+	if err := initializers.DB.Create(&invitation).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create invitation"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":    "Invitation created",
+		"invitation": invitation,
+	})
+}
+
+// GetGuardianInvitations lists the invitations of the patient that can still be redeemed
+func GetGuardianInvitations(c *gin.Context) {
+	patient, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var invitations []models.GuardianInvitation
+	result := initializers.DB.
+		Where("patient_id = ? AND redeemed_at IS NULL AND expires_at > ?", patient.ID, time.Now()).
+		Order("created_at DESC").
+		Find(&invitations)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(200, gin.H{"invitations": invitations})
+}
+
+// DeleteGuardianInvitation cancels an invitation that has not been redeemed yet
+func DeleteGuardianInvitation(c *gin.Context) {
+	patient, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.
+		Where("id = ? AND patient_id = ? AND redeemed_at IS NULL", id, patient.ID).
+		Delete(&models.GuardianInvitation{})
+
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete invitation"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Invitation not found or unauthorized"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Invitation deleted successfully"})
+}
+
+// RedeemGuardianInvitation links the authenticated guardian to the patient who
+// created the invitation. The guardian has to explicitly consent to the link.
+func RedeemGuardianInvitation(c *gin.Context) {
+	guardian, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Code    string `json:"code"`
+		Consent bool   `json:"consent"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if !body.Consent {
+		c.JSON(400, gin.H{"error": "Consent is required to link with a patient"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var invitation models.GuardianInvitation
+	code := strings.ToUpper(strings.TrimSpace(body.Code))
+	if err := initializers.DB.Where("code = ?", code).First(&invitation).Error; err != nil || !invitation.IsRedeemable(time.Now()) {
+		c.JSON(400, gin.H{"error": "Invalid or expired invitation code"})
+		return
+	}
+
+	if invitation.PatientID == guardian.ID {
+		c.JSON(400, gin.H{"error": "You can't become your own guardian"})
+		return
+	}
+
+	if _, err := activeGuardianLink(invitation.PatientID, guardian.ID); err == nil {
+		c.JSON(400, gin.H{"error": "You are already linked to this patient"})
+		return
+	}
+
+	now := time.Now()
+	link := models.Guardian{
+		PatientID:    invitation.PatientID,
+		GuardianID:   guardian.ID,
+		InvitationID: invitation.ID,
+		ConsentedAt:  now,
+		// Meal descriptions and measurements can be sensitive, the patient has to opt in to sharing them
+		ShareGoals:            true,
+		ShareMeals:            true,
+		ShareMealDescriptions: false,
+		ShareMeasurements:     false,
+	}
+
+	tx := initializers.DB.Begin()
+
+	// Claim the invitation first so the same code can't be redeemed twice
+	claim := tx.Model(&models.GuardianInvitation{}).
+		Where("id = ? AND redeemed_at IS NULL", invitation.ID).
+		Updates(map[string]interface{}{"redeemed_at": now, "redeemed_by_id": guardian.ID})
+	if claim.Error != nil || claim.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(400, gin.H{"error": "Invalid or expired invitation code"})
+		return
+	}
+
+	if err := tx.Create(&link).Error; err != nil {
+		tx.Rollback()
+		c.JSON(400, gin.H{"error": "Failed to link guardian"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(500, gin.H{"error": "Failed to link guardian"})
+		return
+	}
+
+	initializers.DB.Preload("Patient").First(&link, link.ID)
+
+	c.JSON(200, gin.H{
+		"message":  "Guardian linked successfully",
+		"guardian": link,
+	})
+}
+
+// RevokeGuardianLink ends a guardian link. Either the patient or the guardian can revoke it.
+func RevokeGuardianLink(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Model(&models.Guardian{}).
+		Where("id = ? AND (patient_id = ? OR guardian_id = ?) AND revoked_at IS NULL", id, user.ID, user.ID).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to revoke guardian link"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Guardian link not found or unauthorized"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Guardian link revoked successfully"})
+}
+
+// UpdateGuardianSharing changes what the patient shares with one of their guardians
+func UpdateGuardianSharing(c *gin.Context) {
+	patient, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	var body struct {
+		ShareGoals            *bool `json:"share_goals"`
+		ShareMeals            *bool `json:"share_meals"`
+		ShareMealDescriptions *bool `json:"share_meal_descriptions"`
+		ShareMeasurements     *bool `json:"share_measurements"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var link models.Guardian
+	if err := initializers.DB.Where("id = ? AND patient_id = ? AND revoked_at IS NULL", id, patient.ID).First(&link).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Guardian link not found or unauthorized"})
+		return
+	}
+
+	if body.ShareGoals != nil {
+		link.ShareGoals = *body.ShareGoals
+	}
+	if body.ShareMeals != nil {
+		link.ShareMeals = *body.ShareMeals
+	}
+	if body.ShareMealDescriptions != nil {
+		link.ShareMealDescriptions = *body.ShareMealDescriptions
+	}
+	if body.ShareMeasurements != nil {
+		link.ShareMeasurements = *body.ShareMeasurements
+	}
+
+	if err := initializers.DB.Save(&link).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update sharing settings"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":  "Sharing settings updated successfully",
+		"guardian": link,
+	})
+}
+
+// GetGuardians lists the active guardians of the authenticated patient
+func GetGuardians(c *gin.Context) {
+	patient, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var links []models.Guardian
+	result := initializers.DB.Preload("Guardian").
+		Where("patient_id = ? AND revoked_at IS NULL", patient.ID).
+		Find(&links)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch guardians"})
+		return
+	}
+
+	c.JSON(200, gin.H{"guardians": links})
+}
+
+// GetPatients lists the patients the authenticated guardian is linked to
+func GetPatients(c *gin.Context) {
+	guardian, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var links []models.Guardian
+	result := initializers.DB.Preload("Patient").
+		Where("guardian_id = ? AND revoked_at IS NULL", guardian.ID).
+		Find(&links)
+
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch patients"})
+		return
+	}
+
+	c.JSON(200, gin.H{"patients": links})
+}
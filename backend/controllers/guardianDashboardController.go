@@ -0,0 +1,188 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxDashboardRangeDays limits how many days of meals a guardian can fetch at once.
+const maxDashboardRangeDays = 92
+
+// sharedNutrilog is the view of a nutrilog a guardian is allowed to see.
+type sharedNutrilog struct {
+	ID              uint                   `json:"id"`
+	Calories        float64                `json:"calories"`
+	Proteins        float64                `json:"proteins"`
+	Fats            float64                `json:"fats"`
+	Carbohydrates   float64                `json:"carbohydrates"`
+	Nutrients       models.NutrientAmounts `json:"nutrients"`
+	MealType        string                 `json:"meal_type"`
+	MealTime        models.TimeOfDay       `json:"meal_time"`
+	MealDate        models.Date            `json:"meal_date"`
+	MealDescription string                 `json:"meal_description,omitempty"`
+}
+
+// guardianPatientLink loads the active link between the authenticated guardian
+// and the patient in the path. Responds with 403 if they aren't linked.
+func guardianPatientLink(c *gin.Context) (models.Guardian, bool) {
+	guardian, ok := currentUser(c)
+	if !ok {
+		return models.Guardian{}, false
+	}
+
+	patientID, err := strconv.ParseUint(c.Param("patient_id"), 10, 32)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid patient ID"})
+		return models.Guardian{}, false
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return models.Guardian{}, false
+	}
+
+	link, err := activeGuardianLink(uint(patientID), guardian.ID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a guardian of this patient"})
+		return models.Guardian{}, false
+	}
+	return link, true
+}
+
+// GetPatientDailySummary returns the daily totals of a patient and, if shared,
+// their progress towards the active nutrition goal
+func GetPatientDailySummary(c *gin.Context) {
+	link, ok := guardianPatientLink(c)
+	if !ok {
+		return
+	}
+
+	// Today is the patient's day, not the guardian's
+	date := models.DateOf(userNow(link.PatientID))
+	if raw := c.Query("date"); raw != "" {
+		var err error
+		if date, err = models.ParseDate(raw); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	summary, err := findDailySummary(link.PatientID, date)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
+		return
+	}
+	totals := stats.SummaryTotals(summary)
+
+	response := gin.H{
+		"patient_id":  link.PatientID,
+		"date":        date,
+		"meal_count":  summary.MealCount,
+		"totals":      totals,
+		"goal_shared": link.ShareGoals,
+	}
+
+	if link.ShareGoals {
+		var nutritionGoal models.NutritionGoal
+		if err := initializers.DB.Where("user_id = ? AND is_active = ?", link.PatientID, true).First(&nutritionGoal).Error; err == nil {
+			response["nutrition_goal"] = nutritionGoal
+			goalStatuses := stats.GoalStatuses(nutritionGoal, totals, stats.GoalTolerance(initializers.DB, link.PatientID))
+			response["goal_statuses"] = goalStatuses
+			response["goal_achieved"] = stats.AllWithin(goalStatuses)
+		}
+	}
+
+	c.JSON(200, response)
+}
+
+// GetPatientNutrilogs returns the meals a patient logged between two dates,
+// leaving out the descriptions unless the patient shares them
+func GetPatientNutrilogs(c *gin.Context) {
+	link, ok := guardianPatientLink(c)
+	if !ok {
+		return
+	}
+
+	if !link.ShareMeals {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This patient does not share their meals"})
+		return
+	}
+
+	today := string(models.DateOf(userNow(link.PatientID)))
+	from, err := models.ParseDate(c.DefaultQuery("from", today))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := models.ParseDate(c.DefaultQuery("to", today))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+		return
+	}
+	if to < from {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
+	if to > from.AddDays(maxDashboardRangeDays) {
+		c.JSON(400, gin.H{"error": "Date range is too large"})
+		return
+	}
+
+	nutrilogs, err := findNutrilogsInRange(link.PatientID, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
+		return
+	}
+
+	shared := make([]sharedNutrilog, 0, len(nutrilogs))
+	for _, log := range nutrilogs {
+		entry := sharedNutrilog{
+			ID:            log.ID,
+			Calories:      log.Calories,
+			Proteins:      log.Proteins,
+			Fats:          log.Fats,
+			Carbohydrates: log.Carbohydrates,
+			Nutrients:     log.Nutrients,
+			MealType:      log.MealType,
+			MealTime:      log.MealTime,
+			MealDate:      log.MealDate,
+		}
+		if link.ShareMealDescriptions {
+			entry.MealDescription = log.MealDescription
+		}
+		shared = append(shared, entry)
+	}
+
+	c.JSON(200, gin.H{
+		"patient_id": link.PatientID,
+		"from":       from,
+		"to":         to,
+		"nutrilogs":  shared,
+	})
+}
+
+// GetPatientNutritionGoal returns the active nutrition goal of a patient
+func GetPatientNutritionGoal(c *gin.Context) {
+	link, ok := guardianPatientLink(c)
+	if !ok {
+		return
+	}
+
+	if !link.ShareGoals {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This patient does not share their goals"})
+		return
+	}
+
+	var nutritionGoal models.NutritionGoal
+	if err := initializers.DB.Where("user_id = ? AND is_active = ?", link.PatientID, true).First(&nutritionGoal).Error; err != nil {
+		c.JSON(404, gin.H{"error": "No active nutrition goal found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"nutrition_goal": nutritionGoal})
+}
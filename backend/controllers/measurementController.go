@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/measurements"
+	"BAZ/Nutritracker/models"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// measurementTrend is the smoothed trend of one kind of measurement.
+type measurementTrend struct {
+	Unit       string                    `json:"unit"`
+	Points     []measurements.TrendPoint `json:"points"`
+	Current    *float64                  `json:"current"`
+	WeeklyRate *float64                  `json:"weekly_rate"`
+}
+
+// parseMeasuredAt reads an RFC 3339 timestamp, defaulting to now. Measurements
+// can't be in the future.
+func parseMeasuredAt(raw string) (time.Time, string) {
+	if raw == "" {
+		return time.Now(), ""
+	}
+	measuredAt, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, "Invalid measured_at, expected an RFC 3339 timestamp"
+	}
+	if measuredAt.After(time.Now().Add(time.Minute)) {
+		return time.Time{}, "measured_at can't be in the future"
+	}
+	return measuredAt, ""
+}
+
+// measurementReport lists the measurements of a user between two of their
+// days, optionally of one kind, with the trend of each kind. Trends are
+// smoothed over all earlier measurements so the first days of the range are
+// as reliable as the last.
+func measurementReport(c *gin.Context, userID uint) {
+	kind := c.Query("type")
+	if kind != "" {
+		if _, ok := models.MeasurementUnits[kind]; !ok {
+			c.JSON(400, gin.H{"error": "Unknown measurement type"})
+			return
+		}
+	}
+
+	var user models.User
+	if err := initializers.DB.First(&user, userID).Error; err != nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+	loc := user.Location()
+
+	today := user.Today(time.Now())
+	from, err := models.ParseDate(c.DefaultQuery("from", string(today.AddDays(-90))))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+		return
+	}
+	to, err := models.ParseDate(c.DefaultQuery("to", string(today)))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+		return
+	}
+	if to < from {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
+	start := from.Time(loc)
+	end := to.AddDays(1).Time(loc)
+
+	query := initializers.DB.Where("user_id = ? AND measured_at < ?", userID, end)
+	if kind != "" {
+		query = query.Where("type = ?", kind)
+	}
+	var entries []models.Measurement
+	if err := query.Order("measured_at, id").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch measurements"})
+		return
+	}
+
+	byKind := map[string][]models.Measurement{}
+	inRange := []models.Measurement{}
+	for _, entry := range entries {
+		byKind[entry.Type] = append(byKind[entry.Type], entry)
+		if !entry.MeasuredAt.Before(start) {
+			inRange = append(inRange, entry)
+		}
+	}
+
+	trends := map[string]measurementTrend{}
+	for entryKind, series := range byKind {
+		points := measurements.Trend(series)
+		first := sort.Search(len(points), func(i int) bool { return !points[i].MeasuredAt.Before(start) })
+		trend := measurementTrend{
+			Unit:       models.MeasurementUnits[entryKind],
+			Points:     points[first:],
+			WeeklyRate: measurements.WeeklyRate(points),
+		}
+		if len(trend.Points) == 0 {
+			continue
+		}
+		current := points[len(points)-1].Trend
+		trend.Current = &current
+		trends[entryKind] = trend
+	}
+
+	// Newest first, like the other lists
+	for i, j := 0, len(inRange)-1; i < j; i, j = i+1, j-1 {
+		inRange[i], inRange[j] = inRange[j], inRange[i]
+	}
+
+	c.JSON(200, gin.H{
+		"from":         from,
+		"to":           to,
+		"measurements": inRange,
+		"trends":       trends,
+	})
+}
+
+// CreateMeasurement records a body measurement, converting it to the stored unit
+func CreateMeasurement(c *gin.Context) {
+	var body struct {
+		UserID     uint    `json:"user_id"`
+		Type       string  `json:"type"`
+		Value      float64 `json:"value"`
+		Unit       string  `json:"unit"`
+		MeasuredAt string  `json:"measured_at"`
+		Note       string  `json:"note"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	value, unit, err := measurements.Normalize(body.Type, body.Value, body.Unit)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	measuredAt, msg := parseMeasuredAt(body.MeasuredAt)
+	if msg != "" {
+		c.JSON(400, gin.H{"error": msg})
+		return
+	}
+
+	// Defaults to the authenticated user, other users need an explicit grant
+	userID, ok := authorizeSubjectID(c, body.UserID, PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	measurement := models.Measurement{
+		UserID:     userID,
+		Type:       body.Type,
+		Value:      value,
+		Unit:       unit,
+		MeasuredAt: measuredAt,
+		Note:       body.Note,
+	}
+
+	if err := initializers.DB.Create(&measurement).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create measurement"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":     "Measurement created",
+		"measurement": measurement,
+	})
+}
+
+// GetMeasurements lists the measurements of a user with their trends
+func GetMeasurements(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionReadMeasurements)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	measurementReport(c, userID)
+}
+
+// UpdateMeasurement changes the value, time or note of a measurement
+func UpdateMeasurement(c *gin.Context) {
+	var body struct {
+		Value      *float64 `json:"value"`
+		Unit       string   `json:"unit"`
+		MeasuredAt *string  `json:"measured_at"`
+		Note       *string  `json:"note"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var measurement models.Measurement
+	if err := initializers.DB.First(&measurement, c.Param("id")).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Measurement not found or unauthorized"})
+		return
+	}
+
+	if !authorizeOwner(c, measurement.UserID, PermissionWrite, "Measurement not found or unauthorized") {
+		return
+	}
+
+	if body.Value != nil {
+		value, unit, err := measurements.Normalize(measurement.Type, *body.Value, body.Unit)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		measurement.Value = value
+		measurement.Unit = unit
+	}
+	if body.MeasuredAt != nil {
+		measuredAt, msg := parseMeasuredAt(*body.MeasuredAt)
+		if msg != "" {
+			c.JSON(400, gin.H{"error": msg})
+			return
+		}
+		measurement.MeasuredAt = measuredAt
+	}
+	if body.Note != nil {
+		measurement.Note = *body.Note
+	}
+
+	if err := initializers.DB.Save(&measurement).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update measurement"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":     "Measurement updated successfully",
+		"measurement": measurement,
+	})
+}
+
+// DeleteMeasurement removes a measurement
+func DeleteMeasurement(c *gin.Context) {
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var measurement models.Measurement
+	if err := initializers.DB.First(&measurement, c.Param("id")).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Measurement not found or unauthorized"})
+		return
+	}
+
+	if !authorizeOwner(c, measurement.UserID, PermissionWrite, "Measurement not found or unauthorized") {
+		return
+	}
+
+	if err := initializers.DB.Delete(&measurement).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete measurement"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Measurement deleted successfully"})
+}
+
+// GetPatientMeasurements lists the measurements of a patient that shares them
+func GetPatientMeasurements(c *gin.Context) {
+	link, ok := guardianPatientLink(c)
+	if !ok {
+		return
+	}
+
+	if !link.ShareMeasurements {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This patient does not share their measurements"})
+		return
+	}
+
+	measurementReport(c, link.PatientID)
+}
@@ -3,7 +3,6 @@ package controllers
 import (
 	"BAZ/Nutritracker/initializers"
 	"BAZ/Nutritracker/models"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,6 +21,12 @@ func CreateMotivationalMessage(c *gin.Context) {
 		return
 	}
 
+	// Defaults to the authenticated user, other users need an explicit grant
+	userID, ok := authorizeSubjectID(c, body.UserID, PermissionWrite)
+	if !ok {
+		return
+	}
+
 	// Check if DB is nil (database connection failed)
 	if initializers.DB == nil {
 		c.JSON(500, gin.H{
@@ -33,7 +38,7 @@ func CreateMotivationalMessage(c *gin.Context) {
 	message := models.MotivationalMessage{
 		Message:      body.Message,
 		MessageType:  body.MessageType,
-		UserID:       body.UserID,
+		UserID:       userID,
 		IsRead:       false,
 		ScheduledFor: body.ScheduledFor,
 	}
@@ -53,7 +58,10 @@ func CreateMotivationalMessage(c *gin.Context) {
 
 // GetMotivationalMessagesByUser gets all motivational messages for a user
 func GetMotivationalMessagesByUser(c *gin.Context) {
-	userID := c.Param("user_id")
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionReadMessages)
+	if !ok {
+		return
+	}
 
 	// Check if DB is nil (database connection failed)
 	if initializers.DB == nil {
@@ -79,7 +87,10 @@ func GetMotivationalMessagesByUser(c *gin.Context) {
 
 // GetUnreadMotivationalMessagesByUser gets all unread motivational messages for a user
 func GetUnreadMotivationalMessagesByUser(c *gin.Context) {
-	userID := c.Param("user_id")
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionReadMessages)
+	if !ok {
+		return
+	}
 
 	// Check if DB is nil (database connection failed)
 	if initializers.DB == nil {
@@ -105,11 +116,11 @@ func GetUnreadMotivationalMessagesByUser(c *gin.Context) {
 
 // GetTimedMotivationalMessages gets motivational messages for a user based on current time
 func GetTimedMotivationalMessages(c *gin.Context) {
-	userID := c.Param("user_id")
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionReadMessages)
+	if !ok {
+		return
+	}
 	
-	// Get current time in HH:MM format
-	currentTime := time.Now().Format("15:04")
-
 	// Check if DB is nil (database connection failed)
 	if initializers.DB == nil {
 		c.JSON(500, gin.H{
@@ -118,10 +129,13 @@ func GetTimedMotivationalMessages(c *gin.Context) {
 		return
 	}
 
+	// Get current time in HH:MM format, in the user's timezone
+	currentTime := userNow(userID).Format("15:04")
+
 	var messages []models.MotivationalMessage
 
-	// Get messages scheduled for this time or general messages
-	result := initializers.DB.Where("user_id = ? AND (scheduled_for = ? OR message_type = 'general')", userID, currentTime).Find(&messages)
+	// Get messages scheduled for this time, general messages and unread notes from buddies
+	result := initializers.DB.Where("user_id = ? AND (scheduled_for = ? OR message_type = 'general' OR (message_type = 'buddy' AND is_read = ?))", userID, currentTime, false).Find(&messages)
 
 	if result.Error != nil {
 		c.Status(400)
@@ -145,7 +159,17 @@ func MarkMessageAsRead(c *gin.Context) {
 		return
 	}
 
-	result := initializers.DB.Model(&models.MotivationalMessage{}).Where("id = ?", id).Update("is_read", true)
+	var message models.MotivationalMessage
+	if err := initializers.DB.First(&message, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Motivational message not found or unauthorized"})
+		return
+	}
+
+	if !authorizeOwner(c, message.UserID, PermissionWrite, "Motivational message not found or unauthorized") {
+		return
+	}
+
+	result := initializers.DB.Model(&message).Update("is_read", true)
 
 	if result.Error != nil {
 		c.Status(400)
@@ -169,7 +193,17 @@ func DeleteMotivationalMessage(c *gin.Context) {
 		return
 	}
 
-	result := initializers.DB.Delete(&models.MotivationalMessage{}, id)
+	var message models.MotivationalMessage
+	if err := initializers.DB.First(&message, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Motivational message not found or unauthorized"})
+		return
+	}
+
+	if !authorizeOwner(c, message.UserID, PermissionWrite, "Motivational message not found or unauthorized") {
+		return
+	}
+
+	result := initializers.DB.Delete(&message)
 
 	if result.Error != nil {
 		c.Status(400)
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications lists the notifications of the authenticated user, newest first
+func GetNotifications(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Where("user_id = ?", user.ID)
+	if c.Query("unread") == "true" {
+		query = query.Where("is_read = ?", false)
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(200, gin.H{"notifications": notifications})
+}
+
+// MarkNotificationAsRead marks a notification of the authenticated user as read
+func MarkNotificationAsRead(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, user.ID).
+		Update("is_read", true)
+
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to update notification"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Notification not found or unauthorized"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Notification marked as read"})
+}
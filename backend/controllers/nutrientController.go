@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNutrients lists the nutrients that can be tracked besides calories and
+// macros, with their units
+func GetNutrients(c *gin.Context) {
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var list []models.Nutrient
+	if err := initializers.DB.Order("id").Find(&list).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrients"})
+		return
+	}
+
+	c.JSON(200, gin.H{"nutrients": list})
+}
@@ -1,13 +1,50 @@
 package controllers
 
 import (
+	"BAZ/Nutritracker/achievements"
 	"BAZ/Nutritracker/initializers"
 	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"BAZ/Nutritracker/stats"
+	"encoding/json"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
 )
 
+// parseMealMoment validates the date and time of a meal. Empty values are
+// left empty.
+func parseMealMoment(date string, mealTime string) (models.Date, models.TimeOfDay, error) {
+	var mealDate models.Date
+	var timeOfDay models.TimeOfDay
+	var err error
+	if date != "" {
+		if mealDate, err = models.ParseDate(date); err != nil {
+			return "", "", err
+		}
+	}
+	if mealTime != "" {
+		if timeOfDay, err = models.ParseTimeOfDay(mealTime); err != nil {
+			return "", "", err
+		}
+	}
+	return mealDate, timeOfDay, nil
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes a search term match literally inside a LIKE pattern.
+func escapeLike(term string) string {
+	return likeEscaper.Replace(term)
+}
+
 func CreateNutrilog(c *gin.Context) {
 	// Get the authenticated user from context
 	user, exists := c.Get("user")
@@ -18,14 +55,18 @@ func CreateNutrilog(c *gin.Context) {
 	authenticatedUser := user.(models.User)
 
 	var body struct {
-		Calories        int    `json:"calories"`
-		Proteins        int    `json:"proteins"`
-		Fats            int    `json:"fats"`
-		Carbohydrates   int    `json:"carbohydrates"`
-		MealType        string `json:"meal_type"`
-		MealTime        string `json:"meal_time"`
-		MealDate        string `json:"meal_date"`
-		MealDescription string `json:"meal_description"`
+		Calories        float64                `json:"calories"`
+		Proteins        float64                `json:"proteins"`
+		Fats            float64                `json:"fats"`
+		Carbohydrates   float64                `json:"carbohydrates"`
+		MealType        string                 `json:"meal_type"`
+		MealTime        string                 `json:"meal_time"`
+		MealDate        string                 `json:"meal_date"`
+		MealDescription string                 `json:"meal_description"`
+		Source          string                 `json:"source"`
+		Nutrients       models.NutrientAmounts `json:"nutrients"`
+		RecipeID        uint                   `json:"recipe_id"`
+		foodPortion
 	}
 
 	if err := c.Bind(&body); err != nil {
@@ -41,28 +82,100 @@ func CreateNutrilog(c *gin.Context) {
 		return
 	}
 
+	// Other sources are set by the server from what the nutrilog was made of
+	if body.Source != "" && body.Source != models.NutrilogSourceManual {
+		c.JSON(400, gin.H{"error": "source can only be manual, send a food_id with its barcode or a recipe_id instead"})
+		return
+	}
+
+	if err := nutrients.Validate(body.Nutrients); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Meals without a date or time are logged now, in the user's timezone
+	mealDate, mealTime, err := parseMealMoment(body.MealDate, body.MealTime)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now().In(authenticatedUser.Location())
+	if mealDate == "" {
+		mealDate = models.DateOf(now)
+	}
+	if mealTime == "" {
+		mealTime = models.TimeOfDayOf(now)
+	}
+
 	nutrilog := models.Nutrilog{
-		Calories:        body.Calories,
-		Proteins:        body.Proteins,
-		Fats:            body.Fats,
-		Carbohydrates:   body.Carbohydrates,
+		Calories:        models.RoundMacro(body.Calories),
+		Proteins:        models.RoundMacro(body.Proteins),
+		Fats:            models.RoundMacro(body.Fats),
+		Carbohydrates:   models.RoundMacro(body.Carbohydrates),
 		MealType:        body.MealType,
-		MealTime:        body.MealTime,
-		MealDate:        body.MealDate,
+		MealTime:        mealTime,
+		MealDate:        mealDate,
 		MealDescription: body.MealDescription,
+		Source:          models.NutrilogSourceManual,
+		Nutrients:       body.Nutrients.Rounded(),
 		UserID:          authenticatedUser.ID,
 	}
 
-	result := initializers.DB.Create(&nutrilog)
+	if body.FoodID != 0 && body.RecipeID != 0 {
+		c.JSON(400, gin.H{"error": "A nutrilog is either a food or a recipe"})
+		return
+	}
+
+	// When logged from the food catalog or a recipe the server computes the nutrients
+	if body.RecipeID != 0 {
+		if err := applyRecipePortion(&nutrilog, authenticatedUser.ID, body.RecipeID, body.Servings); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if body.FoodID != 0 {
+		if err := applyFoodPortion(&nutrilog, body.foodPortion); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tx := initializers.DB.Begin()
+
+	result := tx.Create(&nutrilog)
 
 	if result.Error != nil {
+		tx.Rollback()
+		c.Status(400)
+		return
+	}
+
+	if err := stats.RefreshDailySummaries(tx, authenticatedUser.ID, nutrilog.MealDate); err != nil {
+		tx.Rollback()
+		c.Status(400)
+		return
+	}
+
+	if err := stats.RefreshStreak(tx, authenticatedUser.ID); err != nil {
+		tx.Rollback()
 		c.Status(400)
 		return
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		c.Status(400)
+		return
+	}
+
+	newAchievements, err := achievements.Evaluate(initializers.DB, authenticatedUser.ID, now, 0)
+	if err != nil {
+		log.Printf("Error evaluating achievements for user %d: %v", authenticatedUser.ID, err)
+	}
+
 	c.JSON(200, gin.H{
-		"message":  "Nutrilog created",
-		"nutrilog": nutrilog,
+		"message":          "Nutrilog created",
+		"nutrilog":         nutrilog,
+		"new_achievements": newAchievements,
 	})
 }
 
@@ -146,17 +259,57 @@ func UpdateNutrilogById(c *gin.Context) {
 	id := c.Param("id")
 
 	var body struct {
-		Calories        int    `json:"calories"`
-		Proteins        int    `json:"proteins"`
-		Fats            int    `json:"fats"`
-		Carbohydrates   int    `json:"carbohydrates"`
-		MealType        string `json:"meal_type"`
-		MealTime        string `json:"meal_time"`
-		MealDate        string `json:"meal_date"`
-		MealDescription string `json:"meal_description"`
+		Calories        float64                `json:"calories"`
+		Proteins        float64                `json:"proteins"`
+		Fats            float64                `json:"fats"`
+		Carbohydrates   float64                `json:"carbohydrates"`
+		MealType        string                 `json:"meal_type"`
+		MealTime        string                 `json:"meal_time"`
+		MealDate        string                 `json:"meal_date"`
+		MealDescription string                 `json:"meal_description"`
+		Nutrients       models.NutrientAmounts `json:"nutrients"`
 	}
 
-	c.Bind(&body)
+	// Only the fields that were sent change, so values can be set to zero and nutrients cleared
+	var sent map[string]json.RawMessage
+	if err := c.ShouldBindBodyWith(&sent, binding.JSON); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if err := c.ShouldBindBodyWith(&body, binding.JSON); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	if body.MealDate == "" {
+		// A nutrilog always has a date
+		delete(sent, "meal_date")
+	}
+	var columns []string
+	for _, column := range []string{"calories", "proteins", "fats", "carbohydrates", "meal_type", "meal_time", "meal_date", "meal_description", "nutrients"} {
+		if _, ok := sent[column]; ok {
+			columns = append(columns, column)
+		}
+	}
+	if len(columns) == 0 {
+		c.JSON(400, gin.H{"error": "Nothing to update"})
+		return
+	}
+
+	if _, ok := sent["meal_type"]; ok && !models.ValidMealType(body.MealType) {
+		c.JSON(400, gin.H{"error": "meal_type must be one of " + strings.Join(models.MealTypes, ", ")})
+		return
+	}
+
+	if err := nutrients.Validate(body.Nutrients); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	mealDate, mealTime, err := parseMealMoment(body.MealDate, body.MealTime)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Check if DB is nil (database connection failed)
 	if initializers.DB == nil {
@@ -166,30 +319,60 @@ func UpdateNutrilogById(c *gin.Context) {
 		return
 	}
 
+	tx := initializers.DB.Begin()
+
 	// Only update if the nutrilog belongs to the authenticated user
-	result := initializers.DB.Model(&models.Nutrilog{}).
+	var existing models.Nutrilog
+	if err := tx.Where("id = ? AND user_id = ?", id, authenticatedUser.ID).First(&existing).Error; err != nil {
+		tx.Rollback()
+		c.JSON(404, gin.H{"error": "Nutrilog not found or unauthorized"})
+		return
+	}
+
+	result := tx.Model(&models.Nutrilog{}).
 		Where("id = ? AND user_id = ?", id, authenticatedUser.ID).
+		Select(columns).
 		Updates(models.Nutrilog{
-			Calories:        body.Calories,
-			Proteins:        body.Proteins,
-			Fats:            body.Fats,
-			Carbohydrates:   body.Carbohydrates,
+			Calories:        models.RoundMacro(body.Calories),
+			Proteins:        models.RoundMacro(body.Proteins),
+			Fats:            models.RoundMacro(body.Fats),
+			Carbohydrates:   models.RoundMacro(body.Carbohydrates),
 			MealType:        body.MealType,
-			MealTime:        body.MealTime,
-			MealDate:        body.MealDate,
+			MealTime:        mealTime,
+			MealDate:        mealDate,
 			MealDescription: body.MealDescription,
+			Nutrients:       body.Nutrients.Rounded(),
 		})
 
 	if result.Error != nil {
+		tx.Rollback()
 		c.JSON(400, gin.H{"error": "Failed to update nutrilog"})
 		return
 	}
 
 	if result.RowsAffected == 0 {
+		tx.Rollback()
 		c.JSON(404, gin.H{"error": "Nutrilog not found or unauthorized"})
 		return
 	}
 
+	// The meal date may have changed, keep the summaries of both days and the streak in sync
+	if err := stats.RefreshDailySummaries(tx, authenticatedUser.ID, existing.MealDate, mealDate); err != nil {
+		tx.Rollback()
+		c.JSON(400, gin.H{"error": "Failed to update nutrilog"})
+		return
+	}
+	if err := stats.RefreshStreak(tx, authenticatedUser.ID); err != nil {
+		tx.Rollback()
+		c.JSON(400, gin.H{"error": "Failed to update nutrilog"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update nutrilog"})
+		return
+	}
+
 	c.JSON(200, gin.H{
 		"message": "Nutrilog updated successfully",
 	})
@@ -214,20 +397,154 @@ func DeleteNutrilogById(c *gin.Context) {
 		return
 	}
 
+	tx := initializers.DB.Begin()
+
 	// Only delete if the nutrilog belongs to the authenticated user
-	result := initializers.DB.Where("id = ? AND user_id = ?", id, authenticatedUser.ID).Delete(&models.Nutrilog{})
+	var existing models.Nutrilog
+	if err := tx.Where("id = ? AND user_id = ?", id, authenticatedUser.ID).First(&existing).Error; err != nil {
+		tx.Rollback()
+		c.JSON(404, gin.H{"error": "Nutrilog not found or unauthorized"})
+		return
+	}
+
+	result := tx.Delete(&existing)
 
 	if result.Error != nil {
+		tx.Rollback()
 		c.JSON(400, gin.H{"error": "Failed to delete nutrilog"})
 		return
 	}
 
 	if result.RowsAffected == 0 {
+		tx.Rollback()
 		c.JSON(404, gin.H{"error": "Nutrilog not found or unauthorized"})
 		return
 	}
 
+	if err := stats.RefreshDailySummaries(tx, authenticatedUser.ID, existing.MealDate); err != nil {
+		tx.Rollback()
+		c.JSON(400, gin.H{"error": "Failed to delete nutrilog"})
+		return
+	}
+
+	if err := stats.RefreshStreak(tx, authenticatedUser.ID); err != nil {
+		tx.Rollback()
+		c.JSON(400, gin.H{"error": "Failed to delete nutrilog"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete nutrilog"})
+		return
+	}
+
 	c.JSON(200, gin.H{
 		"message": "Nutrilog deleted successfully",
 	})
 }
+
+// ListNutrilogs returns a page of the nutrilogs of a user, filtered by date
+// range, meal type and description. Pass next_cursor back as cursor to get the
+// following page; total counts all nutrilogs matching the filters.
+func ListNutrilogs(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionReadMeals)
+	if !ok {
+		return
+	}
+
+	limit := defaultNutrilogPageSize
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(400, gin.H{"error": "Invalid limit"})
+			return
+		}
+		if parsed < maxNutrilogPageSize {
+			limit = parsed
+		} else {
+			limit = maxNutrilogPageSize
+		}
+	}
+
+	sort, descending, err := parseNutrilogSort(c.Query("sort"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	var cursor []interface{}
+	if token := c.Query("cursor"); token != "" {
+		if cursor, err = sort.decodeCursor(token); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var from, to models.Date
+	if value := c.Query("from"); value != "" {
+		if from, err = models.ParseDate(value); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = models.ParseDate(value); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if from != "" && to != "" && to < from {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	query := initializers.DB.Model(&models.Nutrilog{}).Where("user_id = ?", userID)
+	if from != "" {
+		query = query.Where("meal_date >= ?", from)
+	}
+	if to != "" {
+		query = query.Where("meal_date <= ?", to)
+	}
+	if mealType := c.Query("meal_type"); mealType != "" {
+		query = query.Where("meal_type = ?", mealType)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("meal_description LIKE ?", "%"+escapeLike(q)+"%")
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
+		return
+	}
+
+	page := query.Session(&gorm.Session{})
+	if cursor != nil {
+		page = sort.after(page, descending, cursor)
+	}
+
+	// One extra row tells whether there is a next page
+	var nutrilogs []models.Nutrilog
+	if err := sort.order(page, descending).Limit(limit + 1).Find(&nutrilogs).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
+		return
+	}
+
+	var nextCursor *string
+	if len(nutrilogs) > limit {
+		nutrilogs = nutrilogs[:limit]
+		token := sort.encodeCursor(nutrilogs[limit-1])
+		nextCursor = &token
+	}
+
+	c.JSON(200, gin.H{
+		"nutrilogs":   nutrilogs,
+		"total":       total,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/internal/fakedb"
+	"BAZ/Nutritracker/models"
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// nutrilogRouter serves nutrilog creation and updates as the patient.
+func nutrilogRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user", models.User{Model: gorm.Model{ID: patientID}})
+	})
+	router.POST("/createnutrilog", CreateNutrilog)
+	router.PUT("/updatenutrilog/:id", UpdateNutrilogById)
+	return router
+}
+
+// patientDay answers the queries run after a nutrilog is logged for a patient
+// without goals, other meals or achievements.
+func patientDay(db *fakedb.DB) {
+	db.On("users", []string{"id", "timezone"}, []driver.Value{int64(patientID), "UTC"})
+	db.OnQuery("SELECT count(*) FROM `nutrilogs`", []string{"count"}, []driver.Value{int64(1)})
+	noRows(db, "food_servings", "nutrilogs", "nutrition_goals", "daily_summaries", "goal_progression_policies",
+		"stats", "achievements", "accomplished_achievements")
+}
+
+func TestCreateNutrilogSource(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantSource string
+	}{
+		{"no source", `{"calories": 300}`, http.StatusOK, models.NutrilogSourceManual},
+		{"manual", `{"calories": 300, "source": "manual"}`, http.StatusOK, models.NutrilogSourceManual},
+		{"barcode claimed by the client", `{"calories": 300, "source": "barcode"}`, http.StatusBadRequest, ""},
+		{"recipe claimed by the client", `{"calories": 300, "source": "recipe"}`, http.StatusBadRequest, ""},
+		{"saved meal claimed by the client", `{"calories": 300, "source": "saved_meal"}`, http.StatusBadRequest, ""},
+		{"copy claimed by the client", `{"calories": 300, "source": "copy"}`, http.StatusBadRequest, ""},
+		{"food from the catalog", `{"food_id": 5, "quantity": 250}`, http.StatusOK, models.NutrilogSourceManual},
+		{"scanned food", `{"food_id": 5, "quantity": 250, "barcode": "4006381333931"}`, http.StatusOK, models.NutrilogSourceBarcode},
+		{"scanned with another barcode", `{"food_id": 5, "quantity": 250, "barcode": "4006381333948"}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.On("foods",
+				[]string{"id", "name", "barcode", "calories_per100"},
+				[]driver.Value{int64(5), "Oat drink", "4006381333931", 46.0},
+			)
+			patientDay(db)
+
+			recorder := serve(nutrilogRouter(), http.MethodPost, "/createnutrilog", tt.body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("POST /createnutrilog = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantSource == "" {
+				return
+			}
+
+			var response struct {
+				Nutrilog models.Nutrilog `json:"nutrilog"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Nutrilog.Source != tt.wantSource {
+				t.Errorf("source = %q, want %q", response.Nutrilog.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestCreateNutrilogKeepsDecimals(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantCalories float64
+		wantProteins float64
+	}{
+		{"manual", `{"calories": 312.456, "proteins": 12.5}`, 312.46, 12.5},
+		{"food portion", `{"food_id": 5, "quantity": 33}`, 15.18, 0.33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.On("foods",
+				[]string{"id", "name", "calories_per100", "proteins_per100"},
+				[]driver.Value{int64(5), "Oat drink", 46.0, 1.0},
+			)
+			patientDay(db)
+
+			recorder := serve(nutrilogRouter(), http.MethodPost, "/createnutrilog", tt.body)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("POST /createnutrilog = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+			}
+
+			var response struct {
+				Nutrilog models.Nutrilog `json:"nutrilog"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Nutrilog.Calories != tt.wantCalories || response.Nutrilog.Proteins != tt.wantProteins {
+				t.Errorf("calories, proteins = %v, %v, want %v, %v",
+					response.Nutrilog.Calories, response.Nutrilog.Proteins, tt.wantCalories, tt.wantProteins)
+			}
+		})
+	}
+}
+
+func TestUpdateNutrilogSentFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantColumns []string
+	}{
+		{"zero calories", `{"calories": 0}`, http.StatusOK, []string{"calories"}},
+		{"cleared nutrients", `{"nutrients": null, "proteins": 0}`, http.StatusOK, []string{"proteins", "nutrients"}},
+		{"meal type in any case", `{"meal_type": "Dinner", "meal_date": "2026-10-17"}`, http.StatusOK, []string{"meal_type", "meal_date"}},
+		{"no date", `{"meal_date": "", "meal_description": ""}`, http.StatusOK, []string{"meal_description"}},
+		{"unknown meal type", `{"meal_type": "brunch"}`, http.StatusBadRequest, nil},
+		{"nothing sent", `{}`, http.StatusBadRequest, nil},
+		{"invalid JSON", `{"calories": `, http.StatusBadRequest, nil},
+		{"calories as text", `{"calories": "300"}`, http.StatusBadRequest, nil},
+	}
+	columns := []string{"calories", "proteins", "fats", "carbohydrates", "meal_type", "meal_time", "meal_date", "meal_description", "nutrients"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := useFakeDB(t)
+			db.On("nutrilogs",
+				[]string{"id", "user_id", "calories", "meal_type", "meal_date"},
+				[]driver.Value{int64(11), int64(patientID), 450.5, "lunch", "2026-10-18"},
+			)
+			patientDay(db)
+
+			recorder := serve(nutrilogRouter(), http.MethodPut, "/updatenutrilog/11", tt.body)
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("PUT /updatenutrilog/11 = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if executed := db.Executed(); len(executed) > 0 {
+					t.Errorf("refused update changed data: %v", executed)
+				}
+				return
+			}
+
+			updates := db.ExecutedOn("nutrilogs")
+			if len(updates) == 0 {
+				t.Fatal("nutrilog wasn't updated")
+			}
+			update := updates[0].SQL
+			want := map[string]bool{"updated_at": true}
+			for _, column := range tt.wantColumns {
+				want[column] = true
+			}
+			for _, column := range columns {
+				if set := strings.Contains(update, "`"+column+"`="); set != want[column] {
+					t.Errorf("%s set = %v, want %v: %s", column, set, want[column], update)
+				}
+			}
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		term string
+		want string
+	}{
+		{"pasta", "pasta"},
+		{"50% fat", `50\% fat`},
+		{"snack_bar", `snack\_bar`},
+		{`a\b`, `a\\b`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLike(tt.term); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/models"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultNutrilogPageSize = 50
+	maxNutrilogPageSize     = 200
+	defaultNutrilogSort     = "-date"
+)
+
+// nutrilogSort is an order nutrilogs can be listed in. The columns always end
+// with the id so every row has a unique position, which keeps cursors stable
+// when rows share a date or a calorie count.
+type nutrilogSort struct {
+	columns []string
+	key     func(models.Nutrilog) []interface{}
+}
+
+// mealTimeKey sorts nutrilogs without a time at the start of their day.
+const mealTimeKey = "COALESCE(meal_time, '00:00:00')"
+
+var nutrilogSorts = map[string]nutrilogSort{
+	"date": {
+		columns: []string{"meal_date", mealTimeKey, "id"},
+		key: func(nutrilog models.Nutrilog) []interface{} {
+			mealTime := "00:00:00"
+			if nutrilog.MealTime != "" {
+				mealTime = string(nutrilog.MealTime) + ":00"
+			}
+			return []interface{}{string(nutrilog.MealDate), mealTime, nutrilog.ID}
+		},
+	},
+	"calories": {
+		columns: []string{"calories", "id"},
+		key: func(nutrilog models.Nutrilog) []interface{} {
+			return []interface{}{nutrilog.Calories, nutrilog.ID}
+		},
+	},
+}
+
+// parseNutrilogSort reads a sort parameter such as "date" or "-calories",
+// where the minus sign means descending.
+func parseNutrilogSort(value string) (nutrilogSort, bool, error) {
+	if value == "" {
+		value = defaultNutrilogSort
+	}
+	name := strings.TrimPrefix(value, "-")
+	sort, ok := nutrilogSorts[name]
+	if !ok {
+		return nutrilogSort{}, false, errors.New("Invalid sort, expected date, -date, calories or -calories")
+	}
+	return sort, name != value, nil
+}
+
+// order sorts the query in this order.
+func (s nutrilogSort) order(query *gorm.DB, descending bool) *gorm.DB {
+	direction := " ASC"
+	if descending {
+		direction = " DESC"
+	}
+	columns := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		columns[i] = column + direction
+	}
+	return query.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:                strings.Join(columns, ", "),
+		WithoutParentheses: true,
+	}})
+}
+
+// after restricts the query to the rows that come after the cursor.
+func (s nutrilogSort) after(query *gorm.DB, descending bool, cursor []interface{}) *gorm.DB {
+	operator := ">"
+	if descending {
+		operator = "<"
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cursor)), ", ")
+	return query.Where(clause.Expr{
+		SQL:  "(" + strings.Join(s.columns, ", ") + ") " + operator + " (" + placeholders + ")",
+		Vars: cursor,
+	})
+}
+
+// encodeCursor turns the sort key of the last row of a page into an opaque token.
+func (s nutrilogSort) encodeCursor(nutrilog models.Nutrilog) string {
+	data, _ := json.Marshal(s.key(nutrilog))
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a token made by encodeCursor for the same sort.
+func (s nutrilogSort) decodeCursor(token string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	var values []interface{}
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != len(s.columns) {
+		return nil, errors.New("Invalid cursor")
+	}
+	for _, value := range values {
+		switch value.(type) {
+		case string, float64:
+		default:
+			return nil, errors.New("Invalid cursor")
+		}
+	}
+	return values, nil
+}
@@ -1,15 +1,27 @@
 package controllers
 
 import (
+	"BAZ/Nutritracker/achievements"
 	"BAZ/Nutritracker/initializers"
 	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"BAZ/Nutritracker/stats"
+	"log"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// refreshTodaySummary re-evaluates today's summary of a user against their
+// new goal. Earlier days keep the goal they had.
+func refreshTodaySummary(userID uint) {
+	today := models.DateOf(userNow(userID))
+	if err := stats.RefreshDailySummaries(initializers.DB, userID, today); err != nil {
+		log.Printf("Error refreshing daily summary for user %d: %v", userID, err)
+	}
+}
+
 func CreateNutritionGoal(c *gin.Context) {
 	var body struct {
 		UserID       uint `json:"user_id"`
@@ -17,6 +29,8 @@ func CreateNutritionGoal(c *gin.Context) {
 		ProteinsGoal int  `json:"proteins_goal"`
 		FatsGoal     int  `json:"fats_goal"`
 		CarbsGoal    int  `json:"carbs_goal"`
+		NutrientGoals models.NutrientAmounts `json:"nutrient_goals"`
+		Ranges        models.GoalRanges      `json:"ranges"`
 	}
 
 	if err := c.Bind(&body); err != nil {
@@ -24,30 +38,47 @@ func CreateNutritionGoal(c *gin.Context) {
 		return
 	}
 
+	if err := nutrients.Validate(body.NutrientGoals); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := stats.ValidateGoalRanges(body.Ranges); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Defaults to the authenticated user, other users need an explicit grant
+	userID, ok := authorizeSubjectID(c, body.UserID, PermissionWrite)
+	if !ok {
+		return
+	}
+
 	if initializers.DB == nil {
 		c.JSON(500, gin.H{"error": "database connection not available"})
 		return
 	}
 
-	// Deactivate previous goals for this user
-	initializers.DB.Model(&models.NutritionGoal{}).Where("user_id = ? AND is_active = ?", body.UserID, true).Update("is_active", false)
-
+	// Replaces the active goal, which stays in the history
 	nutritionGoal := models.NutritionGoal{
-		UserID:       body.UserID,
+		UserID:       userID,
 		CaloriesGoal: body.CaloriesGoal,
 		ProteinsGoal: body.ProteinsGoal,
 		FatsGoal:     body.FatsGoal,
 		CarbsGoal:    body.CarbsGoal,
-		IsActive:     true,
-		StartDate:    time.Now(),
+		NutrientGoals: body.NutrientGoals.Rounded(),
+		Ranges:       body.Ranges,
 	}
 
-	result := initializers.DB.Create(&nutritionGoal)
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceNutritionGoal(tx, &nutritionGoal, userNow(userID))
+	})
 
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(400, gin.H{"error": "Failed to create nutrition goal"})
 		return
 	}
+	refreshTodaySummary(userID)
 
 	c.JSON(200, gin.H{
 		"message":        "Nutrition goal created",
@@ -56,10 +87,8 @@ func CreateNutritionGoal(c *gin.Context) {
 }
 
 func GetActiveNutritionGoal(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionReadGoals)
+	if !ok {
 		return
 	}
 
@@ -69,22 +98,30 @@ func GetActiveNutritionGoal(c *gin.Context) {
 	}
 
 	var nutritionGoal models.NutritionGoal
-	result := initializers.DB.Where("user_id = ? AND is_active = ?", uint(userID), true).First(&nutritionGoal)
+	result := initializers.DB.Where("user_id = ? AND is_active = ?", userID, true).First(&nutritionGoal)
 
 	if result.Error != nil {
-		// Create default goal if none exists
+		// Only the user and those who may change their goals get a default goal created
+		actor, _ := currentUser(c)
+		if !CanAccessUser(actor, userID, PermissionWrite) {
+			c.JSON(404, gin.H{"error": "No active nutrition goal found"})
+			return
+		}
+
+		// Create default goal if none exists, from the body metrics when they are set
 		defaultGoal := models.NutritionGoal{
-			UserID:       uint(userID),
+			UserID:       userID,
 			CaloriesGoal: 2000,
 			ProteinsGoal: 75,
 			FatsGoal:     65,
 			CarbsGoal:    250,
-			IsActive:     true,
-			StartDate:    time.Now(),
+		}
+		if suggestion, err := suggestGoal(userID, "", ""); err == nil {
+			defaultGoal = suggestion.Goal(userID)
 		}
 		
-		createResult := initializers.DB.Create(&defaultGoal)
-		if createResult.Error != nil {
+		createErr := replaceNutritionGoal(initializers.DB, &defaultGoal, userNow(userID))
+		if createErr != nil {
 			c.JSON(400, gin.H{"error": "Failed to create default nutrition goal"})
 			return
 		}
@@ -104,6 +141,8 @@ func UpdateNutritionGoal(c *gin.Context) {
 		ProteinsGoal int `json:"proteins_goal"`
 		FatsGoal     int `json:"fats_goal"`
 		CarbsGoal    int `json:"carbs_goal"`
+		NutrientGoals models.NutrientAmounts `json:"nutrient_goals"`
+		Ranges        models.GoalRanges      `json:"ranges"`
 	}
 
 	if err := c.Bind(&body); err != nil {
@@ -111,31 +150,78 @@ func UpdateNutritionGoal(c *gin.Context) {
 		return
 	}
 
+	if err := nutrients.Validate(body.NutrientGoals); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := stats.ValidateGoalRanges(body.Ranges); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
 	if initializers.DB == nil {
 		c.JSON(500, gin.H{"error": "database connection not available"})
 		return
 	}
 
-	result := initializers.DB.Model(&models.NutritionGoal{}).Where("id = ?", id).Updates(models.NutritionGoal{
-		CaloriesGoal: body.CaloriesGoal,
-		ProteinsGoal: body.ProteinsGoal,
-		FatsGoal:     body.FatsGoal,
-		CarbsGoal:    body.CarbsGoal,
+	var existingGoal models.NutritionGoal
+	if err := initializers.DB.First(&existingGoal, id).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Nutrition goal not found or unauthorized"})
+		return
+	}
+
+	if !authorizeOwner(c, existingGoal.UserID, PermissionWrite, "Nutrition goal not found or unauthorized") {
+		return
+	}
+
+	// Past versions stay as they were in effect
+	if !existingGoal.IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only the active nutrition goal can be updated"})
+		return
+	}
+
+	// The update becomes a new version, keeping the progress of the current one
+	nutritionGoal := existingGoal
+	if body.CaloriesGoal != 0 {
+		nutritionGoal.CaloriesGoal = body.CaloriesGoal
+	}
+	if body.ProteinsGoal != 0 {
+		nutritionGoal.ProteinsGoal = body.ProteinsGoal
+	}
+	if body.FatsGoal != 0 {
+		nutritionGoal.FatsGoal = body.FatsGoal
+	}
+	if body.CarbsGoal != 0 {
+		nutritionGoal.CarbsGoal = body.CarbsGoal
+	}
+	if body.NutrientGoals != nil {
+		nutritionGoal.NutrientGoals = body.NutrientGoals.Rounded()
+	}
+	if body.Ranges != nil {
+		nutritionGoal.Ranges = body.Ranges
+	}
+	nutritionGoal.GoalChangeID = nil
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		return replaceNutritionGoal(tx, &nutritionGoal, userNow(existingGoal.UserID))
 	})
 
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(400, gin.H{"error": "Failed to update nutrition goal"})
 		return
 	}
+	refreshTodaySummary(existingGoal.UserID)
 
-	c.JSON(200, gin.H{"message": "Nutrition goal updated successfully"})
+	c.JSON(200, gin.H{
+		"message":        "Nutrition goal updated successfully",
+		"nutrition_goal": nutritionGoal,
+	})
 }
 
 func CheckAndUpdateGoalProgress(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionWrite)
+	if !ok {
 		return
 	}
 
@@ -146,40 +232,32 @@ func CheckAndUpdateGoalProgress(c *gin.Context) {
 
 	// Get active nutrition goal
 	var nutritionGoal models.NutritionGoal
-	result := initializers.DB.Where("user_id = ? AND is_active = ?", uint(userID), true).First(&nutritionGoal)
+	result := initializers.DB.Where("user_id = ? AND is_active = ?", userID, true).First(&nutritionGoal)
 	if result.Error != nil {
 		c.JSON(400, gin.H{"error": "No active nutrition goal found"})
 		return
 	}
 
-	// Get today's nutrilogs
-	today := time.Now().Format("2006-01-02")
-	var todayNutrilogs []models.Nutrilog
-	initializers.DB.Where("user_id = ? AND meal_date = ?", uint(userID), today).Find(&todayNutrilogs)
-
-	// Calculate today's totals
-	var totalCalories, totalProteins, totalFats, totalCarbs int
-	for _, log := range todayNutrilogs {
-		totalCalories += log.Calories
-		totalProteins += log.Proteins
-		totalFats += log.Fats
-		totalCarbs += log.Carbohydrates
-	}
+	// Get today's totals, today being the user's day
+	now := userNow(userID)
+	today := models.DateOf(now)
+	summary, _ := findDailySummary(userID, today)
+	totals := stats.SummaryTotals(summary)
 
-	// Check if goals are achieved (within 10% tolerance)
-	caloriesAchieved := float64(totalCalories) >= float64(nutritionGoal.CaloriesGoal)*0.9
-	proteinsAchieved := float64(totalProteins) >= float64(nutritionGoal.ProteinsGoal)*0.9
-	fatsAchieved := float64(totalFats) >= float64(nutritionGoal.FatsGoal)*0.9
-	carbsAchieved := float64(totalCarbs) >= float64(nutritionGoal.CarbsGoal)*0.9
+	// Check if every nutrient is within its range, allowing the tolerance of the user's policy
+	policy := goalProgressionPolicy(userID)
+	goalStatuses := stats.GoalStatuses(nutritionGoal, totals, policy.Tolerance)
+	goalAchieved := stats.AllWithin(goalStatuses)
 
-	goalAchieved := caloriesAchieved && proteinsAchieved && fatsAchieved && carbsAchieved
+	// Streak before a goal change resets it, used for achievements
+	goalStreak := 0
+	var goalChange *models.GoalChange
 
 	if goalAchieved {
-		now := time.Now()
 		// Check if this is a consecutive day
 		if nutritionGoal.LastAchievedDate != nil {
-			yesterday := now.AddDate(0, 0, -1).Format("2006-01-02")
-			lastAchievedDate := nutritionGoal.LastAchievedDate.Format("2006-01-02")
+			yesterday := today.AddDays(-1)
+			lastAchievedDate := models.DateOf(nutritionGoal.LastAchievedDate.In(now.Location()))
 			
 			if lastAchievedDate == yesterday {
 				nutritionGoal.GoalAchievedDays++
@@ -191,49 +269,48 @@ func CheckAndUpdateGoalProgress(c *gin.Context) {
 		}
 		
 		nutritionGoal.LastAchievedDate = &now
+		goalStreak = nutritionGoal.GoalAchievedDays
 		
-		// Check if goals should be increased (after 7 consecutive days)
-		shouldIncreaseGoals := nutritionGoal.GoalAchievedDays >= 7
-		
-		if shouldIncreaseGoals {
-			// Increase goals by 5%
-			nutritionGoal.CaloriesGoal = int(float64(nutritionGoal.CaloriesGoal) * 1.05)
-			nutritionGoal.ProteinsGoal = int(float64(nutritionGoal.ProteinsGoal) * 1.05)
-			nutritionGoal.FatsGoal = int(float64(nutritionGoal.FatsGoal) * 1.05)
-			nutritionGoal.CarbsGoal = int(float64(nutritionGoal.CarbsGoal) * 1.05)
-			nutritionGoal.GoalAchievedDays = 0
-			nutritionGoal.StartDate = now
+		// Let the progression policy decide whether the goals change
+		err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if goalChange, err = progressGoal(tx, &nutritionGoal, policy, now); err != nil {
+				return err
+			}
+			return tx.Save(&nutritionGoal).Error
+		})
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Failed to update goal progress"})
+			return
+		}
+		if goalChange != nil && goalChange.Status == models.GoalChangeApplied {
+			refreshTodaySummary(userID)
 		}
-		
-		initializers.DB.Save(&nutritionGoal)
+	}
+
+	newAchievements, err := achievements.Evaluate(initializers.DB, userID, now, goalStreak)
+	if err != nil {
+		log.Printf("Error evaluating achievements for user %d: %v", userID, err)
 	}
 
 	c.JSON(200, gin.H{
 		"goal_achieved":        goalAchieved,
+		"goal_statuses":        goalStatuses,
 		"consecutive_days":     nutritionGoal.GoalAchievedDays,
-		"goals_increased":      goalAchieved && nutritionGoal.GoalAchievedDays >= 7,
-		"current_totals": gin.H{
-			"calories":      totalCalories,
-			"proteins":      totalProteins,
-			"fats":          totalFats,
-			"carbohydrates": totalCarbs,
-		},
+		"goals_increased":      goalChange != nil && goalChange.Status == models.GoalChangeApplied,
+		"goal_change":          goalChange,
+		"current_totals":       totals,
 		"nutrition_goal": nutritionGoal,
+		"new_achievements":     newAchievements,
 	})
 }
 
 func GetNutrilogsByUserAndDate(c *gin.Context) {
-	userIDStr := c.Param("user_id")
 	date := c.Query("date")
-	
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
-		return
-	}
 
-	if date == "" {
-		date = time.Now().Format("2006-01-02")
+	userID, ok := authorizeSubject(c, c.Param("user_id"), PermissionReadMeals)
+	if !ok {
+		return
 	}
 
 	if initializers.DB == nil {
@@ -241,16 +318,24 @@ func GetNutrilogsByUserAndDate(c *gin.Context) {
 		return
 	}
 
-	var nutrilogs []models.Nutrilog
-	result := initializers.DB.Where("user_id = ? AND meal_date = ?", uint(userID), date).Find(&nutrilogs)
+	day := models.DateOf(userNow(userID))
+	if date != "" {
+		var err error
+		if day, err = models.ParseDate(date); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
-	if result.Error != nil {
+	nutrilogs, err := findNutrilogsByDate(userID, day)
+
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"nutrilogs": nutrilogs,
-		"date":      date,
+		"date":      day,
 	})
 }
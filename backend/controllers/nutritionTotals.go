@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+)
+
+// findNutrilogsByDate returns the nutrilogs of a user for one day.
+func findNutrilogsByDate(userID uint, date models.Date) ([]models.Nutrilog, error) {
+	var nutrilogs []models.Nutrilog
+	err := initializers.DB.Where("user_id = ? AND meal_date = ?", userID, date).Find(&nutrilogs).Error
+	return nutrilogs, err
+}
+
+// findNutrilogsInRange returns the nutrilogs of a user between two days (inclusive),
+// ordered by date and time.
+func findNutrilogsInRange(userID uint, from models.Date, to models.Date) ([]models.Nutrilog, error) {
+	var nutrilogs []models.Nutrilog
+	err := initializers.DB.
+		Where("user_id = ? AND meal_date >= ? AND meal_date <= ?", userID, from, to).
+		Order("meal_date, meal_time").
+		Find(&nutrilogs).Error
+	return nutrilogs, err
+}
+
+// findDailySummary returns the summary of a user for one day. Days without
+// nutrilogs have an empty summary.
+func findDailySummary(userID uint, date models.Date) (models.DailySummary, error) {
+	var summaries []models.DailySummary
+	err := initializers.DB.Where("user_id = ? AND date = ?", userID, date).Limit(1).Find(&summaries).Error
+	if err != nil || len(summaries) == 0 {
+		return models.DailySummary{UserID: userID, Date: date}, err
+	}
+	return summaries[0], nil
+}
@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// recipeBody is the request body to create or replace a recipe. An ingredient
+// line references a catalog food with a quantity, or names a free ingredient
+// with its nutrients.
+type recipeBody struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Servings    float64 `json:"servings"`
+	Ingredients []struct {
+		FoodID        uint                   `json:"food_id"`
+		Name          string                 `json:"name"`
+		Quantity      float64                `json:"quantity"`
+		Calories      float64                `json:"calories"`
+		Proteins      float64                `json:"proteins"`
+		Fats          float64                `json:"fats"`
+		Carbohydrates float64                `json:"carbohydrates"`
+		Nutrients     models.NutrientAmounts `json:"nutrients"`
+	} `json:"ingredients"`
+}
+
+// recipeIngredients validates the body and computes the ingredient lines.
+func (body recipeBody) recipeIngredients() ([]models.RecipeIngredient, error) {
+	if strings.TrimSpace(body.Name) == "" {
+		return nil, errors.New("Name is required")
+	}
+	if body.Servings <= 0 {
+		return nil, errors.New("Servings must be greater than zero")
+	}
+	if len(body.Ingredients) == 0 {
+		return nil, errors.New("A recipe needs at least one ingredient")
+	}
+
+	var ingredients []models.RecipeIngredient
+	for _, line := range body.Ingredients {
+		ingredient := models.RecipeIngredient{
+			Name:     strings.TrimSpace(line.Name),
+			Quantity: line.Quantity,
+		}
+
+		if line.FoodID != 0 {
+			if line.Quantity <= 0 {
+				return nil, errors.New("Ingredient quantity must be greater than zero")
+			}
+			var food models.Food
+			if err := initializers.DB.First(&food, line.FoodID).Error; err != nil {
+				return nil, errors.New("Ingredient food not found")
+			}
+			amounts := food.NutrientsFor(line.Quantity)
+			ingredient.FoodID = &food.ID
+			if ingredient.Name == "" {
+				ingredient.Name = food.Name
+			}
+			ingredient.Calories = amounts.Calories
+			ingredient.Proteins = amounts.Proteins
+			ingredient.Fats = amounts.Fats
+			ingredient.Carbohydrates = amounts.Carbohydrates
+			ingredient.Nutrients = amounts.Nutrients.Rounded()
+		} else {
+			if ingredient.Name == "" {
+				return nil, errors.New("Ingredients need a food or a name")
+			}
+			if line.Quantity < 0 || line.Calories < 0 || line.Proteins < 0 || line.Fats < 0 || line.Carbohydrates < 0 {
+				return nil, errors.New("Ingredient values can't be negative")
+			}
+			ingredient.Calories = line.Calories
+			ingredient.Proteins = line.Proteins
+			ingredient.Fats = line.Fats
+			ingredient.Carbohydrates = line.Carbohydrates
+			if err := nutrients.Validate(line.Nutrients); err != nil {
+				return nil, err
+			}
+			ingredient.Nutrients = line.Nutrients.Rounded()
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+	return ingredients, nil
+}
+
+// findRecipe loads a recipe of the user with its ingredients and nutrition.
+func findRecipe(id interface{}, userID uint) (models.Recipe, error) {
+	var recipe models.Recipe
+	err := initializers.DB.Preload("Ingredients").Where("id = ? AND user_id = ?", id, userID).First(&recipe).Error
+	recipe.CalculateNutrition()
+	return recipe, err
+}
+
+// CreateRecipe saves a recipe of the authenticated user
+func CreateRecipe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body recipeBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	ingredients, err := body.recipeIngredients()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	recipe := models.Recipe{
+		UserID:      user.ID,
+		Name:        strings.TrimSpace(body.Name),
+		Description: body.Description,
+		Servings:    body.Servings,
+		Ingredients: ingredients,
+	}
+	if err := initializers.DB.Create(&recipe).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to create recipe"})
+		return
+	}
+	recipe.CalculateNutrition()
+
+	c.JSON(200, gin.H{
+		"message": "Recipe created",
+		"recipe":  recipe,
+	})
+}
+
+// GetRecipes lists the recipes of the authenticated user
+func GetRecipes(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var recipes []models.Recipe
+	result := initializers.DB.Preload("Ingredients").Where("user_id = ?", user.ID).Order("name").Find(&recipes)
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch recipes"})
+		return
+	}
+	for i := range recipes {
+		recipes[i].CalculateNutrition()
+	}
+
+	c.JSON(200, gin.H{"recipes": recipes})
+}
+
+// GetRecipe returns a recipe with its nutrition in total and per serving
+func GetRecipe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	recipe, err := findRecipe(c.Param("id"), user.ID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"recipe": recipe})
+}
+
+// UpdateRecipe replaces a recipe and its ingredients. Nutrilogs logged from
+// the recipe keep the values they were logged with.
+func UpdateRecipe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body recipeBody
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	recipe, err := findRecipe(c.Param("id"), user.ID)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	ingredients, err := body.recipeIngredients()
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("recipe_id = ?", recipe.ID).Delete(&models.RecipeIngredient{}).Error; err != nil {
+			return err
+		}
+		for i := range ingredients {
+			ingredients[i].RecipeID = recipe.ID
+		}
+		if err := tx.Create(&ingredients).Error; err != nil {
+			return err
+		}
+		return tx.Model(&recipe).Select("name", "description", "servings").Updates(models.Recipe{
+			Name:        strings.TrimSpace(body.Name),
+			Description: body.Description,
+			Servings:    body.Servings,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to update recipe"})
+		return
+	}
+
+	recipe.Ingredients = ingredients
+	recipe.CalculateNutrition()
+
+	c.JSON(200, gin.H{
+		"message": "Recipe updated",
+		"recipe":  recipe,
+	})
+}
+
+// DeleteRecipe deletes a recipe. Nutrilogs logged from it are kept.
+func DeleteRecipe(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).Delete(&models.Recipe{})
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete recipe"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Recipe not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Recipe deleted"})
+}
+
+// applyRecipePortion fills in a nutrilog from servings of a recipe of the
+// user. The nutrients are copied, so editing the recipe later doesn't change the log.
+func applyRecipePortion(nutrilog *models.Nutrilog, userID uint, recipeID uint, servings float64) error {
+	if servings == 0 {
+		servings = 1
+	}
+	if servings < 0 {
+		return errors.New("servings must be greater than zero")
+	}
+
+	recipe, err := findRecipe(recipeID, userID)
+	if err != nil {
+		return errors.New("recipe not found")
+	}
+
+	amounts := recipe.PerServing.Scaled(servings)
+	nutrilog.Calories, nutrilog.Proteins, nutrilog.Fats, nutrilog.Carbohydrates = amounts.Rounded()
+	nutrilog.Nutrients = amounts.Nutrients.Rounded()
+	nutrilog.RecipeID = &recipe.ID
+	nutrilog.Servings = servings
+	nutrilog.Source = models.NutrilogSourceRecipe
+	if nutrilog.MealDescription == "" {
+		nutrilog.MealDescription = recipe.Name
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/achievements"
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// logNutrilogs creates several nutrilogs of a user at once and evaluates the
+// achievements they may have earned.
+func logNutrilogs(userID uint, nutrilogs []models.Nutrilog) ([]models.Achievement, error) {
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&nutrilogs).Error; err != nil {
+			return err
+		}
+		dates := make([]models.Date, len(nutrilogs))
+		for i, nutrilog := range nutrilogs {
+			dates[i] = nutrilog.MealDate
+		}
+		if err := stats.RefreshDailySummaries(tx, userID, dates...); err != nil {
+			return err
+		}
+		return stats.RefreshStreak(tx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	newAchievements, err := achievements.Evaluate(initializers.DB, userID, userNow(userID), 0)
+	if err != nil {
+		log.Printf("Error evaluating achievements for user %d: %v", userID, err)
+	}
+	return newAchievements, nil
+}
+
+// CreateSavedMeal saves a favorite meal from one nutrilog, or from all the
+// nutrilogs of a meal type on a date
+func CreateSavedMeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Name       string `json:"name"`
+		NutrilogID uint   `json:"nutrilog_id"`
+		MealDate   string `json:"meal_date"`
+		MealType   string `json:"meal_type"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		c.JSON(400, gin.H{"error": "Name is required"})
+		return
+	}
+	if body.NutrilogID == 0 && (body.MealDate == "" || body.MealType == "") {
+		c.JSON(400, gin.H{"error": "Either nutrilog_id or meal_date and meal_type are required"})
+		return
+	}
+	mealDate, _, err := parseMealMoment(body.MealDate, "")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var nutrilogs []models.Nutrilog
+	query := initializers.DB.Where("user_id = ?", user.ID)
+	if body.NutrilogID != 0 {
+		query = query.Where("id = ?", body.NutrilogID)
+	} else {
+		query = query.Where("meal_date = ? AND meal_type = ?", mealDate, body.MealType)
+	}
+	if err := query.Order("meal_time").Find(&nutrilogs).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
+		return
+	}
+	if len(nutrilogs) == 0 {
+		c.JSON(404, gin.H{"error": "No nutrilogs found to save"})
+		return
+	}
+
+	savedMeal := models.SavedMeal{
+		UserID:   user.ID,
+		Name:     body.Name,
+		MealType: nutrilogs[0].MealType,
+	}
+	for _, nutrilog := range nutrilogs {
+		savedMeal.Items = append(savedMeal.Items, models.NewSavedMealItem(nutrilog))
+	}
+
+	if err := initializers.DB.Create(&savedMeal).Error; err != nil {
+		c.JSON(400, gin.H{"error": "Failed to save meal"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":    "Meal saved",
+		"saved_meal": savedMeal,
+	})
+}
+
+// GetSavedMeals lists the favorite meals of the authenticated user
+func GetSavedMeals(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var savedMeals []models.SavedMeal
+	result := initializers.DB.Preload("Items").Where("user_id = ?", user.ID).Order("name").Find(&savedMeals)
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch saved meals"})
+		return
+	}
+
+	c.JSON(200, gin.H{"saved_meals": savedMeals})
+}
+
+// DeleteSavedMeal deletes a favorite meal. Nutrilogs logged from it are kept.
+func DeleteSavedMeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	result := initializers.DB.Where("id = ? AND user_id = ?", c.Param("id"), user.ID).Delete(&models.SavedMeal{})
+	if result.Error != nil {
+		c.JSON(400, gin.H{"error": "Failed to delete saved meal"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(404, gin.H{"error": "Saved meal not found"})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Saved meal deleted"})
+}
+
+// LogSavedMeal logs all items of a favorite meal on a date, today by default.
+// The meal type and time default to those of the saved meal.
+func LogSavedMeal(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		MealDate string `json:"meal_date"`
+		MealTime string `json:"meal_time"`
+		MealType string `json:"meal_type"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	mealDate, mealTime, err := parseMealMoment(body.MealDate, body.MealTime)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if mealDate == "" {
+		mealDate = user.Today(time.Now())
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var savedMeal models.SavedMeal
+	if err := initializers.DB.Preload("Items").Where("id = ? AND user_id = ?", c.Param("id"), user.ID).First(&savedMeal).Error; err != nil {
+		c.JSON(404, gin.H{"error": "Saved meal not found"})
+		return
+	}
+
+	if body.MealType == "" {
+		body.MealType = savedMeal.MealType
+	}
+
+	var nutrilogs []models.Nutrilog
+	for _, item := range savedMeal.Items {
+		nutrilog := item.Nutrilog()
+		nutrilog.UserID = user.ID
+		nutrilog.MealDate = mealDate
+		nutrilog.MealType = body.MealType
+		nutrilog.Source = models.NutrilogSourceSavedMeal
+		if mealTime != "" {
+			nutrilog.MealTime = mealTime
+		}
+		nutrilogs = append(nutrilogs, nutrilog)
+	}
+	if len(nutrilogs) == 0 {
+		c.JSON(400, gin.H{"error": "Saved meal has no items"})
+		return
+	}
+
+	newAchievements, err := logNutrilogs(user.ID, nutrilogs)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to log saved meal"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":          "Saved meal logged",
+		"nutrilogs":        nutrilogs,
+		"new_achievements": newAchievements,
+	})
+}
+
+// CopyMeal duplicates the nutrilogs of a user for a meal type from one date to
+// another, by default from yesterday to today
+func CopyMeal(c *gin.Context) {
+	var body struct {
+		UserID   uint   `json:"user_id"`
+		MealType string `json:"meal_type"`
+		FromDate string `json:"from_date"`
+		ToDate   string `json:"to_date"`
+		MealTime string `json:"meal_time"`
+	}
+
+	if err := c.Bind(&body); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	userID, ok := authorizeSubjectID(c, body.UserID, PermissionWrite)
+	if !ok {
+		return
+	}
+
+	if body.MealType == "" {
+		c.JSON(400, gin.H{"error": "meal_type is required"})
+		return
+	}
+
+	fromDate, mealTime, err := parseMealMoment(body.FromDate, body.MealTime)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	toDate, _, err := parseMealMoment(body.ToDate, "")
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	// Yesterday and today of the user whose meal is copied
+	today := models.DateOf(userNow(userID))
+	if fromDate == "" {
+		fromDate = today.AddDays(-1)
+	}
+	if toDate == "" {
+		toDate = today
+	}
+
+	var originals []models.Nutrilog
+	result := initializers.DB.Where("user_id = ? AND meal_date = ? AND meal_type = ?", userID, fromDate, body.MealType).
+		Order("meal_time").Find(&originals)
+	if result.Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to fetch nutrilogs"})
+		return
+	}
+	if len(originals) == 0 {
+		c.JSON(404, gin.H{"error": "No nutrilogs found for this meal"})
+		return
+	}
+
+	nutrilogs := make([]models.Nutrilog, len(originals))
+	for i, original := range originals {
+		nutrilogs[i] = models.NewSavedMealItem(original).Nutrilog()
+		nutrilogs[i].UserID = userID
+		nutrilogs[i].MealDate = toDate
+		nutrilogs[i].MealType = body.MealType
+		nutrilogs[i].Source = models.NutrilogSourceCopy
+		if mealTime != "" {
+			nutrilogs[i].MealTime = mealTime
+		}
+	}
+
+	newAchievements, err := logNutrilogs(userID, nutrilogs)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Failed to copy meal"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":          "Meal copied",
+		"nutrilogs":        nutrilogs,
+		"new_achievements": newAchievements,
+	})
+}
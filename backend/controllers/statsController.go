@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStats returns the summary of a user: their logging streak, open cases and
+// earned achievements. Defaults to the authenticated user.
+func GetStats(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionRead)
+	if !ok {
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	var record models.Stats
+	if err := initializers.DB.Where("user_id = ?", userID).First(&record).Error; err != nil {
+		// Users that never logged anything have no stats yet
+		record = models.Stats{UserID: userID}
+	}
+
+	record.Streak = stats.CurrentStreak(record, userNow(userID))
+
+	c.JSON(200, gin.H{"stats": record})
+}
+
+// maxAggregateRangeDays limits how many days can be aggregated at once.
+const maxAggregateRangeDays = 731
+
+// GetAggregateStats returns the nutrition of a user per day, week or month
+// with totals, daily averages, the macro split and how often the goal of the
+// day was reached. Dates are days in the user's timezone; by default the range
+// ends today and covers 30 days, 12 weeks or 12 months.
+func GetAggregateStats(c *gin.Context) {
+	userID, ok := authorizeSubject(c, c.Query("user_id"), PermissionReadGoals)
+	if !ok {
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", stats.GranularityDay)
+	if granularity != stats.GranularityDay && granularity != stats.GranularityWeek && granularity != stats.GranularityMonth {
+		c.JSON(400, gin.H{"error": "Invalid granularity, expected day, week or month"})
+		return
+	}
+
+	if initializers.DB == nil {
+		c.JSON(500, gin.H{"error": "database connection not available"})
+		return
+	}
+
+	to := models.DateOf(userNow(userID))
+	if value := c.Query("to"); value != "" {
+		var err error
+		if to, err = models.ParseDate(value); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid to date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	var from models.Date
+	switch granularity {
+	case stats.GranularityDay:
+		from = to.AddDays(-29)
+	case stats.GranularityWeek:
+		from = stats.BucketStart(to, granularity).AddDays(-7 * 11)
+	case stats.GranularityMonth:
+		from = models.DateOf(stats.BucketStart(to, granularity).Time(time.UTC).AddDate(0, -11, 0))
+	}
+	if value := c.Query("from"); value != "" {
+		var err error
+		if from, err = models.ParseDate(value); err != nil {
+			c.JSON(400, gin.H{"error": "Invalid from date, expected YYYY-MM-DD"})
+			return
+		}
+	}
+
+	if to < from {
+		c.JSON(400, gin.H{"error": "from must be before to"})
+		return
+	}
+	if to > from.AddDays(maxAggregateRangeDays) {
+		c.JSON(400, gin.H{"error": "Date range is too large"})
+		return
+	}
+
+	buckets, err := stats.Aggregate(initializers.DB, userID, granularity, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to aggregate nutrilogs"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"granularity": granularity,
+		"from":        from,
+		"to":          to,
+		"buckets":     buckets,
+	})
+}
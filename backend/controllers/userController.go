@@ -7,11 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -42,15 +40,8 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	//jwt token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 30).Unix(), // 30 days expiration
-	})
-
-	// Sign and get the complete encoded token as a string using the secret
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-
+	// Start a new session with a short-lived access token and a refresh token
+	tokenString, refreshToken, err := issueSession(c, user)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "Failed to sign token",
@@ -58,16 +49,8 @@ func UserLogin(c *gin.Context) {
 		return
 	}
 
-	// Set cookie with proper settings
-	c.SetCookie(
-		"usertoken",
-		tokenString,
-		3600*24*30, // 30 days
-		"/",
-		"",
-		true, // secure
-		true, // httpOnly
-	)
+	// Set cookies with proper settings
+	setAuthCookies(c, tokenString, refreshToken)
 
 	// Send response with user data (excluding sensitive info)
 	userResponse := gin.H{
@@ -77,12 +60,16 @@ func UserLogin(c *gin.Context) {
 		"first_name":   user.FirstName,
 		"last_name":    user.LastName,
 		"phone_number": user.PhoneNumber,
+		"role":         user.Role,
+		"timezone":     user.Timezone,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"user":    userResponse,
-		"token":   tokenString,
+		"message":       "Login successful",
+		"user":          userResponse,
+		"token":         tokenString,
+		"refresh_token": refreshToken,
+		"expires_in":    int(helpers.AccessTokenTTL.Seconds()),
 	})
 }
 
@@ -94,12 +81,20 @@ func UpdateUser(c *gin.Context) {
 		FirstName   string `json:"first_name"`
 		LastName    string `json:"last_name"`
 		PhoneNumber string `json:"phone_number"`
+		Timezone    string `json:"timezone"`
 	}
 
 	if err := helpers.BindRequest(c, &body); err != nil {
 		return
 	}
 
+	if !validTimezone(body.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid timezone, expected an IANA name such as Europe/Amsterdam",
+		})
+		return
+	}
+
 	// if checkUserExists(body.Email) {
 	// 	c.JSON(http.StatusBadRequest, gin.H{
 	// 		"error": "user already exists",
@@ -129,6 +124,9 @@ func UpdateUser(c *gin.Context) {
 	user.FirstName = body.FirstName
 	user.LastName = body.LastName
 	user.PhoneNumber = body.PhoneNumber
+	if body.Timezone != "" {
+		user.Timezone = body.Timezone
+	}
 
 	if err := initializers.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -196,11 +194,32 @@ func UserRegister(c *gin.Context) {
 		FirstName   string `json:"first_name"`
 		LastName    string `json:"last_name"`
 		PhoneNumber string `json:"phone_number"`
+		Role        string `json:"role"`
+		Timezone    string `json:"timezone"`
 	}
 
 	if err := helpers.BindRequest(c, &body); err != nil {
 		return
 	}
+
+	if !validTimezone(body.Timezone) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid timezone, expected an IANA name such as Europe/Amsterdam",
+		})
+		return
+	}
+
+	// Only patients and guardians can sign themselves up, other roles are granted by an admin
+	if body.Role == "" {
+		body.Role = models.RolePatient
+	}
+	if body.Role != models.RolePatient && body.Role != models.RoleGuardian {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid role",
+		})
+		return
+	}
+
 	user, err := checkUserExists(body.Email)
 
 	if err == nil {
@@ -225,6 +244,8 @@ func UserRegister(c *gin.Context) {
 		FirstName:   body.FirstName,
 		LastName:    body.LastName,
 		PhoneNumber: body.PhoneNumber,
+		Role:        body.Role,
+		Timezone:    body.Timezone,
 	}
 	if err := initializers.DB.Create(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -251,6 +272,23 @@ func UserRegister(c *gin.Context) {
 	//save user to database
 }
 
+// validTimezone reports whether name is empty or a known IANA timezone.
+func validTimezone(name string) bool {
+	if name == "" {
+		return true
+	}
+	_, err := models.LoadLocation(name)
+	return err == nil
+}
+
+// userNow returns the current time in the timezone of a user, so dates like
+// today and yesterday match the user's calendar.
+func userNow(userID uint) time.Time {
+	var user models.User
+	initializers.DB.Select("id", "timezone").First(&user, userID)
+	return time.Now().In(user.Location())
+}
+
 func hashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -0,0 +1,82 @@
+// Package energy estimates the energy needs of a user from their body
+// metrics and turns them into calorie and macro goals.
+package energy
+
+import (
+	"BAZ/Nutritracker/models"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Formulas for the basal metabolic rate.
+const (
+	MifflinStJeor  = "mifflin_st_jeor"
+	HarrisBenedict = "harris_benedict"
+)
+
+// DefaultFormula is the formula used when none is chosen. Mifflin-St Jeor is
+// the more accurate of both for most adults.
+const DefaultFormula = MifflinStJeor
+
+// activityFactors multiply the basal metabolic rate into the total daily
+// energy expenditure.
+var activityFactors = map[string]float64{
+	models.ActivitySedentary:  1.2,
+	models.ActivityLight:      1.375,
+	models.ActivityModerate:   1.55,
+	models.ActivityActive:     1.725,
+	models.ActivityVeryActive: 1.9,
+}
+
+// Age returns the age in whole years on a day.
+func Age(birthDate models.Date, on models.Date) int {
+	birth := birthDate.Time(time.UTC)
+	day := on.Time(time.UTC)
+	age := day.Year() - birth.Year()
+	if day.Month() < birth.Month() || (day.Month() == birth.Month() && day.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
+
+// BMR returns the basal metabolic rate in kcal per day.
+func BMR(formula string, sex string, age int, weightKg float64, heightCm float64) (float64, error) {
+	if !models.IsValidSex(sex) {
+		return 0, errors.New("sex must be male or female")
+	}
+	male := sex == models.SexMale
+
+	switch formula {
+	case MifflinStJeor:
+		// Mifflin et al., 1990
+		bmr := 10*weightKg + 6.25*heightCm - 5*float64(age)
+		if male {
+			return bmr + 5, nil
+		}
+		return bmr - 161, nil
+	case HarrisBenedict:
+		// Harris-Benedict as revised by Roza and Shizgal, 1984
+		if male {
+			return 88.362 + 13.397*weightKg + 4.799*heightCm - 5.677*float64(age), nil
+		}
+		return 447.593 + 9.247*weightKg + 3.098*heightCm - 4.330*float64(age), nil
+	}
+	return 0, fmt.Errorf("unknown formula %q", formula)
+}
+
+// TDEE returns the total daily energy expenditure in kcal for a basal
+// metabolic rate and an activity level.
+func TDEE(bmr float64, activityLevel string) (float64, error) {
+	factor, ok := activityFactors[activityLevel]
+	if !ok {
+		return 0, fmt.Errorf("unknown activity level %q", activityLevel)
+	}
+	return bmr * factor, nil
+}
+
+// round keeps one decimal.
+func round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
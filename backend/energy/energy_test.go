@@ -0,0 +1,164 @@
+package energy
+
+import (
+	"BAZ/Nutritracker/models"
+	"math"
+	"testing"
+)
+
+func TestBMR(t *testing.T) {
+	tests := []struct {
+		name     string
+		formula  string
+		sex      string
+		age      int
+		weightKg float64
+		heightCm float64
+		want     float64
+		wantErr  bool
+	}{
+		{"mifflin male", MifflinStJeor, models.SexMale, 30, 80, 180, 1780, false},
+		{"mifflin female", MifflinStJeor, models.SexFemale, 30, 60, 165, 1320.25, false},
+		{"harris benedict male", HarrisBenedict, models.SexMale, 30, 80, 180, 1853.632, false},
+		{"harris benedict female", HarrisBenedict, models.SexFemale, 30, 60, 165, 1383.683, false},
+		{"older is lower", MifflinStJeor, models.SexMale, 70, 80, 180, 1580, false},
+		{"unknown sex", MifflinStJeor, "", 30, 80, 180, 0, true},
+		{"unknown formula", "katch_mcardle", models.SexMale, 30, 80, 180, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BMR(tt.formula, tt.sex, tt.age, tt.weightKg, tt.heightCm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BMR() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("BMR() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTDEE(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    float64
+		wantErr bool
+	}{
+		{models.ActivitySedentary, 1200, false},
+		{models.ActivityLight, 1375, false},
+		{models.ActivityModerate, 1550, false},
+		{models.ActivityActive, 1725, false},
+		{models.ActivityVeryActive, 1900, false},
+		{"athlete", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			got, err := TDEE(1000, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TDEE() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("TDEE() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		birthDate models.Date
+		on        models.Date
+		want      int
+	}{
+		{"1990-06-15", "2026-06-14", 35},
+		{"1990-06-15", "2026-06-15", 36},
+		{"1990-06-15", "2026-06-16", 36},
+		{"1990-06-15", "2026-01-01", 35},
+		{"1990-06-15", "2026-12-31", 36},
+		{"2000-02-29", "2025-02-28", 24},
+		{"2000-02-29", "2025-03-01", 25},
+		{"2000-02-29", "2024-02-29", 24},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.birthDate)+" on "+string(tt.on), func(t *testing.T) {
+			if got := Age(tt.birthDate, tt.on); got != tt.want {
+				t.Errorf("Age() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	metrics := models.BodyMetrics{
+		Sex:           models.SexMale,
+		BirthDate:     "1996-01-01",
+		HeightCm:      180,
+		WeightKg:      80,
+		ActivityLevel: models.ActivityModerate,
+	}
+
+	tests := []struct {
+		name     string
+		metrics  models.BodyMetrics
+		formula  string
+		strategy string
+		want     Suggestion
+		wantErr  bool
+	}{
+		{
+			name:    "defaults to balanced mifflin",
+			metrics: metrics,
+			want: Suggestion{Formula: MifflinStJeor, Strategy: StrategyBalanced, Age: 30, WeightKg: 80, BMR: 1780, TDEE: 2759,
+				MacroSplit: strategies[StrategyBalanced], CaloriesGoal: 2759, ProteinsGoal: 138, FatsGoal: 92, CarbsGoal: 345},
+		},
+		{
+			name:     "high protein",
+			metrics:  metrics,
+			strategy: StrategyHighProtein,
+			want: Suggestion{Formula: MifflinStJeor, Strategy: StrategyHighProtein, Age: 30, WeightKg: 80, BMR: 1780, TDEE: 2759,
+				MacroSplit: strategies[StrategyHighProtein], CaloriesGoal: 2759, ProteinsGoal: 207, FatsGoal: 92, CarbsGoal: 276},
+		},
+		{
+			name:     "low carb",
+			metrics:  metrics,
+			strategy: StrategyLowCarb,
+			want: Suggestion{Formula: MifflinStJeor, Strategy: StrategyLowCarb, Age: 30, WeightKg: 80, BMR: 1780, TDEE: 2759,
+				MacroSplit: strategies[StrategyLowCarb], CaloriesGoal: 2759, ProteinsGoal: 207, FatsGoal: 138, CarbsGoal: 172},
+		},
+		{
+			name:     "harris benedict",
+			metrics:  metrics,
+			formula:  HarrisBenedict,
+			strategy: StrategyBalanced,
+			want: Suggestion{Formula: HarrisBenedict, Strategy: StrategyBalanced, Age: 30, WeightKg: 80, BMR: 1853.6, TDEE: 2873.1,
+				MacroSplit: strategies[StrategyBalanced], CaloriesGoal: 2873, ProteinsGoal: 144, FatsGoal: 96, CarbsGoal: 359},
+		},
+		{name: "unknown strategy", metrics: metrics, strategy: "carnivore", wantErr: true},
+		{name: "missing weight", metrics: models.BodyMetrics{Sex: models.SexMale, BirthDate: "1996-01-01", HeightCm: 180, ActivityLevel: models.ActivityModerate}, wantErr: true},
+		{name: "missing activity level", metrics: models.BodyMetrics{Sex: models.SexMale, BirthDate: "1996-01-01", HeightCm: 180, WeightKg: 80}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Suggest(tt.metrics, "2026-10-18", tt.formula, tt.strategy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Suggest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Suggest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategiesAddUpToAllEnergy(t *testing.T) {
+	for name, split := range strategies {
+		if total := split.Proteins + split.Fats + split.Carbohydrates; total != 100 {
+			t.Errorf("strategy %s splits %v%% of the energy, want 100%%", name, total)
+		}
+	}
+}
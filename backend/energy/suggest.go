@@ -0,0 +1,110 @@
+package energy
+
+import (
+	"BAZ/Nutritracker/models"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Strategies for splitting the energy over the macros.
+const (
+	StrategyBalanced    = "balanced"
+	StrategyHighProtein = "high_protein"
+	StrategyLowCarb     = "low_carb"
+)
+
+// DefaultStrategy is the strategy used when none is chosen.
+const DefaultStrategy = StrategyBalanced
+
+// Energy per gram of each macro, in kcal.
+const (
+	kcalPerGramProtein      = 4
+	kcalPerGramFat          = 9
+	kcalPerGramCarbohydrate = 4
+)
+
+// MacroSplit is the share of the energy, in percent, each macro provides.
+type MacroSplit struct {
+	Proteins      float64 `json:"proteins"`
+	Fats          float64 `json:"fats"`
+	Carbohydrates float64 `json:"carbohydrates"`
+}
+
+// strategies are the macro splits of each strategy. Balanced follows the
+// middle of the acceptable macronutrient distribution ranges.
+var strategies = map[string]MacroSplit{
+	StrategyBalanced:    {Proteins: 20, Fats: 30, Carbohydrates: 50},
+	StrategyHighProtein: {Proteins: 30, Fats: 30, Carbohydrates: 40},
+	StrategyLowCarb:     {Proteins: 30, Fats: 45, Carbohydrates: 25},
+}
+
+// Suggestion is a nutrition goal computed from body metrics, with the numbers
+// it was computed from.
+type Suggestion struct {
+	Formula      string     `json:"formula"`
+	Strategy     string     `json:"strategy"`
+	Age          int        `json:"age"`
+	WeightKg     float64    `json:"weight_kg"`
+	BMR          float64    `json:"bmr"`
+	TDEE         float64    `json:"tdee"`
+	MacroSplit   MacroSplit `json:"macro_split"`
+	CaloriesGoal int        `json:"calories_goal"`
+	ProteinsGoal int        `json:"proteins_goal"`
+	FatsGoal     int        `json:"fats_goal"`
+	CarbsGoal    int        `json:"carbs_goal"`
+}
+
+// Goal returns the suggestion as a nutrition goal of a user.
+func (s Suggestion) Goal(userID uint) models.NutritionGoal {
+	return models.NutritionGoal{
+		UserID:       userID,
+		CaloriesGoal: s.CaloriesGoal,
+		ProteinsGoal: s.ProteinsGoal,
+		FatsGoal:     s.FatsGoal,
+		CarbsGoal:    s.CarbsGoal,
+	}
+}
+
+// Suggest computes a nutrition goal from the body metrics of a user on a day.
+// Empty formula and strategy use the defaults.
+func Suggest(metrics models.BodyMetrics, on models.Date, formula string, strategy string) (Suggestion, error) {
+	if formula == "" {
+		formula = DefaultFormula
+	}
+	if strategy == "" {
+		strategy = DefaultStrategy
+	}
+	split, ok := strategies[strategy]
+	if !ok {
+		return Suggestion{}, fmt.Errorf("unknown strategy %q", strategy)
+	}
+	if metrics.BirthDate == "" || metrics.HeightCm <= 0 || metrics.WeightKg <= 0 {
+		return Suggestion{}, errors.New("body metrics need a birth date, height and weight")
+	}
+
+	age := Age(metrics.BirthDate, on)
+	bmr, err := BMR(formula, metrics.Sex, age, metrics.WeightKg, metrics.HeightCm)
+	if err != nil {
+		return Suggestion{}, err
+	}
+	tdee, err := TDEE(bmr, metrics.ActivityLevel)
+	if err != nil {
+		return Suggestion{}, err
+	}
+
+	calories := math.Round(tdee)
+	return Suggestion{
+		Formula:      formula,
+		Strategy:     strategy,
+		Age:          age,
+		WeightKg:     metrics.WeightKg,
+		BMR:          round(bmr),
+		TDEE:         round(tdee),
+		MacroSplit:   split,
+		CaloriesGoal: int(calories),
+		ProteinsGoal: int(math.Round(calories * split.Proteins / 100 / kcalPerGramProtein)),
+		FatsGoal:     int(math.Round(calories * split.Fats / 100 / kcalPerGramFat)),
+		CarbsGoal:    int(math.Round(calories * split.Carbohydrates / 100 / kcalPerGramCarbohydrate)),
+	}, nil
+}
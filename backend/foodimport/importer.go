@@ -0,0 +1,354 @@
+package foodimport
+
+import (
+	"BAZ/Nutritracker/models"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultBatchSize is how many rows are written per statement.
+const DefaultBatchSize = 500
+
+// Importer streams food dumps into the catalog. Foods are keyed by their
+// source and source ID, so running an import again updates them in place.
+type Importer struct {
+	DB        *gorm.DB
+	BatchSize int
+	// Report receives one CSV line per skipped row when set.
+	Report *csv.Writer
+
+	Summary Summary
+}
+
+// Summary counts what an import did.
+type Summary struct {
+	Imported int
+	Skipped  map[string]int
+}
+
+// SkippedTotal is the number of skipped rows over all reasons.
+func (s Summary) SkippedTotal() int {
+	total := 0
+	for _, count := range s.Skipped {
+		total += count
+	}
+	return total
+}
+
+func (s Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d rows imported, %d skipped", s.Imported, s.SkippedTotal())
+
+	reasons := make([]string, 0, len(s.Skipped))
+	for reason := range s.Skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(&b, "\n  %-24s %d", reason, s.Skipped[reason])
+	}
+	return b.String()
+}
+
+func (im *Importer) batchSize() int {
+	if im.BatchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return im.BatchSize
+}
+
+// skip records a row that wasn't imported.
+func (im *Importer) skip(file string, line int64, id string, reason string) {
+	if im.Summary.Skipped == nil {
+		im.Summary.Skipped = map[string]int{}
+	}
+	im.Summary.Skipped[reason]++
+	if im.Report != nil {
+		im.Report.Write([]string{file, fmt.Sprint(line), id, reason})
+	}
+}
+
+// pendingFood is a food waiting in a batch with where it came from, for the report.
+type pendingFood struct {
+	food models.Food
+	file string
+	line int64
+}
+
+// upsertFoods writes a batch of foods from one source, updating the given
+// columns of foods that were imported before. Servings of the batch replace
+// the stored ones.
+func (im *Importer) upsertFoods(source string, batch []pendingFood, columns []string) error {
+	batch = im.dedupe(batch)
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return im.DB.Transaction(func(tx *gorm.DB) error {
+		batch, err := im.releaseTakenBarcodes(tx, source, batch)
+		if err != nil {
+			return err
+		}
+
+		foods := make([]models.Food, len(batch))
+		for i, pending := range batch {
+			foods[i] = pending.food
+			foods[i].Source = source
+			foods[i].Servings = nil
+		}
+
+		err = tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "source"}, {Name: "source_id"}},
+			DoUpdates: clause.AssignmentColumns(append(columns, "updated_at")),
+		}).Create(&foods).Error
+		if err != nil {
+			return err
+		}
+
+		if err := replaceServings(tx, source, batch); err != nil {
+			return err
+		}
+		im.Summary.Imported += len(batch)
+		return nil
+	})
+}
+
+// updateFoods sets the given columns of foods that were imported before from
+// another file of the source. Rows of unknown foods are skipped.
+func (im *Importer) updateFoods(source string, batch []pendingFood, columns []string) error {
+	batch = im.dedupe(batch)
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return im.DB.Transaction(func(tx *gorm.DB) error {
+		batch, err := im.releaseTakenBarcodes(tx, source, batch)
+		if err != nil {
+			return err
+		}
+
+		known := batch[:0]
+		for _, pending := range batch {
+			result := tx.Model(&models.Food{}).Where("source = ? AND source_id = ?", source, *pending.food.SourceID).
+				Select(append(columns, "updated_at")).Updates(pending.food)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				im.skip(pending.file, pending.line, *pending.food.SourceID, "unknown_food")
+				continue
+			}
+			known = append(known, pending)
+		}
+
+		if err := replaceServings(tx, source, known); err != nil {
+			return err
+		}
+		im.Summary.Imported += len(known)
+		return nil
+	})
+}
+
+// replaceServings replaces the stored servings of the foods of a batch that
+// come with servings.
+func replaceServings(tx *gorm.DB, source string, batch []pendingFood) error {
+	var sourceIDs []string
+	for _, pending := range batch {
+		if len(pending.food.Servings) > 0 {
+			sourceIDs = append(sourceIDs, *pending.food.SourceID)
+		}
+	}
+	if len(sourceIDs) == 0 {
+		return nil
+	}
+
+	// The IDs of upserted rows aren't returned by every database, so look them up
+	ids, err := foodIDs(tx, source, sourceIDs)
+	if err != nil {
+		return err
+	}
+
+	var servings []models.FoodServing
+	var withServings []uint
+	for _, pending := range batch {
+		id, ok := ids[*pending.food.SourceID]
+		if !ok || len(pending.food.Servings) == 0 {
+			continue
+		}
+		withServings = append(withServings, id)
+		for _, serving := range pending.food.Servings {
+			serving.ID = 0
+			serving.FoodID = id
+			servings = append(servings, serving)
+		}
+	}
+	if len(withServings) == 0 {
+		return nil
+	}
+	if err := tx.Unscoped().Where("food_id IN ?", withServings).Delete(&models.FoodServing{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&servings).Error
+}
+
+// foodIDs maps source IDs of a source to the IDs of the stored foods.
+func foodIDs(tx *gorm.DB, source string, sourceIDs []string) (map[string]uint, error) {
+	var stored []models.Food
+	if err := tx.Select("id", "source_id").Where("source = ? AND source_id IN ?", source, sourceIDs).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(stored))
+	for _, food := range stored {
+		ids[*food.SourceID] = food.ID
+	}
+	return ids, nil
+}
+
+// dedupe keeps the last row of a source ID or barcode that occurs more than once in a batch.
+func (im *Importer) dedupe(batch []pendingFood) []pendingFood {
+	lastBySource := map[string]int{}
+	lastByBarcode := map[string]int{}
+	for i, pending := range batch {
+		lastBySource[*pending.food.SourceID] = i
+		if pending.food.Barcode != nil {
+			lastByBarcode[*pending.food.Barcode] = i
+		}
+	}
+
+	kept := batch[:0]
+	for i, pending := range batch {
+		if lastBySource[*pending.food.SourceID] != i {
+			im.skip(pending.file, pending.line, *pending.food.SourceID, "duplicate")
+			continue
+		}
+		if pending.food.Barcode != nil && lastByBarcode[*pending.food.Barcode] != i {
+			im.skip(pending.file, pending.line, *pending.food.SourceID, "duplicate_barcode")
+			continue
+		}
+		kept = append(kept, pending)
+	}
+	return kept
+}
+
+// releaseTakenBarcodes drops foods whose barcode already belongs to a food of
+// another source or ID. Upserting them would overwrite that food instead.
+func (im *Importer) releaseTakenBarcodes(tx *gorm.DB, source string, batch []pendingFood) ([]pendingFood, error) {
+	var barcodes []string
+	for _, pending := range batch {
+		if pending.food.Barcode != nil {
+			barcodes = append(barcodes, *pending.food.Barcode)
+		}
+	}
+	if len(barcodes) == 0 {
+		return batch, nil
+	}
+
+	var owners []models.Food
+	if err := tx.Unscoped().Select("barcode", "source", "source_id").Where("barcode IN ?", barcodes).Find(&owners).Error; err != nil {
+		return nil, err
+	}
+	owner := make(map[string]models.Food, len(owners))
+	for _, food := range owners {
+		owner[*food.Barcode] = food
+	}
+
+	kept := batch[:0]
+	for _, pending := range batch {
+		if pending.food.Barcode != nil {
+			taken, ok := owner[*pending.food.Barcode]
+			if ok && (taken.Source != source || taken.SourceID == nil || *taken.SourceID != *pending.food.SourceID) {
+				im.skip(pending.file, pending.line, *pending.food.SourceID, "barcode_taken")
+				continue
+			}
+		}
+		kept = append(kept, pending)
+	}
+	return kept, nil
+}
+
+// plausible reports whether per-100 nutrients can be real; dumps contain typos
+// such as energy in the protein column.
+func plausible(food models.Food) bool {
+	values := []float64{food.ProteinsPer100, food.FatsPer100, food.CarbohydratesPer100}
+	for _, value := range values {
+		if value < 0 || value > 100 {
+			return false
+		}
+	}
+	return food.CaloriesPer100 >= 0 && food.CaloriesPer100 <= 900
+}
+
+// openInput opens a dump, decompressing it on the fly when it ends in .gz.
+func openInput(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{reader, file}, nil
+}
+
+// newCSVReader returns a lenient reader for large dumps: rows are reused and
+// may have any number of fields.
+func newCSVReader(r io.Reader, comma rune) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	return reader
+}
+
+// malformedRow reports whether a read error only concerns the current row, so
+// the reader can go on with the next one. Other errors, such as a corrupt gzip
+// stream, repeat on every read and end the import.
+func malformedRow(err error) bool {
+	var parseErr *csv.ParseError
+	return errors.As(err, &parseErr)
+}
+
+// columnIndex maps the names of a CSV header to their positions.
+func columnIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimPrefix(strings.TrimSpace(name), "\ufeff")] = i
+	}
+	return index
+}
+
+// field returns a column of a row, or "" when the row is too short.
+func field(row []string, index map[string]int, name string) string {
+	i, ok := index[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// progress logs every so many rows so long imports show they're alive.
+func progress(file string, line int64, started time.Time) {
+	if line%1000000 == 0 {
+		fmt.Printf("%s: %d rows in %s\n", file, line, time.Since(started).Round(time.Second))
+	}
+}
@@ -0,0 +1,94 @@
+package foodimport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// truncatedGzip writes a gzipped CSV file that ends in the middle of its data.
+func truncatedGzip(t *testing.T, name string, content string) string {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buffer.Bytes()[:buffer.Len()-12], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// within fails the test if fn doesn't return in time, as a reader stuck on
+// the same error would loop forever.
+func within(t *testing.T, fn func() error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("import didn't stop on a read error")
+		return nil
+	}
+}
+
+func TestMalformedRow(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"parse error", &csv.ParseError{Line: 2, Err: csv.ErrQuote}, true},
+		{"wrapped parse error", fmt.Errorf("row: %w", &csv.ParseError{Line: 2, Err: csv.ErrFieldCount}), true},
+		{"corrupt stream", gzip.ErrChecksum, false},
+		{"other error", errors.New("read failed"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := malformedRow(tt.err); got != tt.want {
+				t.Errorf("malformedRow(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportStopsOnCorruptInput(t *testing.T) {
+	var rows bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&rows, "%013d\tFood %d\n", i, i)
+	}
+
+	t.Run("open food facts", func(t *testing.T) {
+		path := truncatedGzip(t, "products.csv.gz", "code\tproduct_name\n"+rows.String())
+		im := &Importer{}
+		if err := within(t, func() error { return im.ImportOpenFoodFacts(path) }); err == nil {
+			t.Error("ImportOpenFoodFacts() of a corrupt file succeeded")
+		}
+	})
+
+	t.Run("usda", func(t *testing.T) {
+		path := truncatedGzip(t, "food.csv.gz", "fdc_id,description\n"+rows.String())
+		err := within(t, func() error {
+			return usdaCSV(path, func(row []string, index map[string]int, line int64) error { return nil })
+		})
+		if err == nil {
+			t.Error("usdaCSV() of a corrupt file succeeded")
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package foodimport
+
+import (
+	"BAZ/Nutritracker/barcode"
+	"BAZ/Nutritracker/models"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxJSONLine bounds a single product of the JSONL export.
+const maxJSONLine = 64 << 20
+
+var offColumns = []string{"name", "brand", "barcode", "unit", "calories_per100", "proteins_per100", "fats_per100", "carbohydrates_per100", "nutrients_per100"}
+
+// ImportOpenFoodFacts imports an Open Food Facts export: the tab separated
+// CSV, or the JSONL data dump when the file name contains .json. Both may be
+// gzipped.
+func (im *Importer) ImportOpenFoodFacts(path string) error {
+	input, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	name := filepath.Base(path)
+	if strings.Contains(name, ".json") {
+		return im.importOpenFoodFactsJSONL(name, input)
+	}
+	return im.importOpenFoodFactsCSV(name, input)
+}
+
+func (im *Importer) importOpenFoodFactsCSV(name string, input io.Reader) error {
+	reader := newCSVReader(input, '\t')
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	index := columnIndex(header)
+	if _, ok := index["code"]; !ok {
+		return errors.New(name + ": not an Open Food Facts CSV export, the code column is missing")
+	}
+
+	number := func(row []string, column string) barcode.FlexibleNum {
+		value, _ := strconv.ParseFloat(field(row, index, column), 64)
+		return barcode.FlexibleNum(value)
+	}
+
+	// Nutriment columns are named like the fields of the API
+	var nutrimentColumns []string
+	for column := range index {
+		if strings.HasSuffix(column, "_100g") {
+			nutrimentColumns = append(nutrimentColumns, column)
+		}
+	}
+
+	var batch []pendingFood
+	started := time.Now()
+	var line int64 = 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		progress(name, line, started)
+		if malformedRow(err) {
+			im.skip(name, line, "", "malformed_row")
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: line %d: %w", name, line, err)
+		}
+
+		product := barcode.OpenFoodFactsProduct{
+			Code:            field(row, index, "code"),
+			ProductName:     field(row, index, "product_name"),
+			Brands:          field(row, index, "brands"),
+			ServingSize:     field(row, index, "serving_size"),
+			ServingQuantity: number(row, "serving_quantity"),
+			Nutriments:      barcode.OpenFoodFactsNutriments{},
+		}
+		for _, column := range nutrimentColumns {
+			if value := number(row, column); value != 0 {
+				product.Nutriments[column] = value
+			}
+		}
+		if food, ok := im.offFood(name, line, product); ok {
+			batch = append(batch, pendingFood{food: food, file: name, line: line})
+		}
+
+		if len(batch) >= im.batchSize() {
+			if err := im.upsertFoods(models.FoodSourceOpenFoodFacts, batch, offColumns); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	return im.upsertFoods(models.FoodSourceOpenFoodFacts, batch, offColumns)
+}
+
+func (im *Importer) importOpenFoodFactsJSONL(name string, input io.Reader) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 1<<20), maxJSONLine)
+
+	var batch []pendingFood
+	started := time.Now()
+	var line int64
+	for scanner.Scan() {
+		line++
+		progress(name, line, started)
+
+		var product barcode.OpenFoodFactsProduct
+		if err := json.Unmarshal(scanner.Bytes(), &product); err != nil {
+			im.skip(name, line, "", "malformed_row")
+			continue
+		}
+		if food, ok := im.offFood(name, line, product); ok {
+			batch = append(batch, pendingFood{food: food, file: name, line: line})
+		}
+
+		if len(batch) >= im.batchSize() {
+			if err := im.upsertFoods(models.FoodSourceOpenFoodFacts, batch, offColumns); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return im.upsertFoods(models.FoodSourceOpenFoodFacts, batch, offColumns)
+}
+
+// offFood validates a product and converts it to a food, recording why it
+// was skipped otherwise.
+func (im *Importer) offFood(name string, line int64, product barcode.OpenFoodFactsProduct) (models.Food, bool) {
+	code := strings.TrimSpace(product.Code)
+	switch {
+	case code == "":
+		im.skip(name, line, "", "missing_code")
+		return models.Food{}, false
+	case !barcode.ValidBarcode(code):
+		im.skip(name, line, code, "invalid_barcode")
+		return models.Food{}, false
+	case strings.TrimSpace(product.ProductName) == "":
+		im.skip(name, line, code, "missing_name")
+		return models.Food{}, false
+	case product.Nutriments["energy-kcal_100g"] <= 0 && product.Nutriments["energy_100g"] <= 0:
+		im.skip(name, line, code, "missing_energy")
+		return models.Food{}, false
+	}
+
+	food := barcode.FoodFromOpenFoodFacts(code, product)
+	if !plausible(food) {
+		im.skip(name, line, code, "implausible_nutrients")
+		return models.Food{}, false
+	}
+	fitColumns(&food)
+	return food, true
+}
+
+// fitColumns shortens texts that don't fit their column.
+func fitColumns(food *models.Food) {
+	food.Name = truncate(food.Name, 255)
+	food.Brand = truncate(food.Brand, 255)
+
+	servings := food.Servings[:0]
+	for _, serving := range food.Servings {
+		if serving.Quantity > 0 {
+			serving.Name = truncate(serving.Name, 100)
+			servings = append(servings, serving)
+		}
+	}
+	food.Servings = servings
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length])
+}
@@ -0,0 +1,565 @@
+package foodimport
+
+import (
+	"BAZ/Nutritracker/barcode"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// usdaDataTypes are the FoodData Central data types imported; the others are
+// lab samples and acquisitions rather than foods.
+var usdaDataTypes = map[string]bool{
+	"branded_food":      true,
+	"foundation_food":   true,
+	"sr_legacy_food":    true,
+	"survey_fndds_food": true,
+}
+
+// usdaNutrient tells how a FoodData Central nutrient maps to a food column,
+// or to a key of the other nutrients of the food.
+type usdaNutrient struct {
+	column string
+	key    string
+	unit   string  // unit stored: KCAL for energy, else G, MG or UG
+	rank   int     // lower wins when a food has several energy values
+	factor float64 // from the unit of the download, set by loadUSDANutrients
+}
+
+// usdaNutrientNumbers maps nutrient numbers, which are stable across releases
+// unlike nutrient IDs, to what we store.
+var usdaNutrientNumbers = map[string]usdaNutrient{
+	"208": {column: "calories_per100", unit: "KCAL", rank: 0}, // Energy, kcal
+	"957": {column: "calories_per100", unit: "KCAL", rank: 1}, // Energy (Atwater General Factors)
+	"958": {column: "calories_per100", unit: "KCAL", rank: 2}, // Energy (Atwater Specific Factors)
+	"268": {column: "calories_per100", unit: "KCAL", rank: 3}, // Energy, kJ
+	"203": {column: "proteins_per100", unit: "G"},             // Protein
+	"204": {column: "fats_per100", unit: "G"},                 // Total lipid (fat)
+	"205": {column: "carbohydrates_per100", unit: "G"},        // Carbohydrate, by difference
+	"291": {key: nutrients.Fiber, unit: "G"},                  // Fiber, total dietary
+	"269": {key: nutrients.Sugar, unit: "G"},                  // Sugars, total
+	"606": {key: nutrients.SaturatedFat, unit: "G"},           // Fatty acids, total saturated
+	"307": {key: nutrients.Sodium, unit: "MG"},                // Sodium, Na
+	"601": {key: nutrients.Cholesterol, unit: "MG"},           // Cholesterol
+	"255": {key: nutrients.Water, unit: "G"},                  // Water, 1 g is taken as 1 ml
+	"306": {key: nutrients.Potassium, unit: "MG"},             // Potassium, K
+	"301": {key: nutrients.Calcium, unit: "MG"},               // Calcium, Ca
+	"303": {key: nutrients.Iron, unit: "MG"},                  // Iron, Fe
+	"320": {key: nutrients.VitaminA, unit: "UG"},              // Vitamin A, RAE
+	"401": {key: nutrients.VitaminC, unit: "MG"},              // Vitamin C, total ascorbic acid
+	"328": {key: nutrients.VitaminD, unit: "UG"},              // Vitamin D (D2 + D3)
+}
+
+// usdaUnits are the size of a unit relative to the base unit of its kind.
+var usdaUnits = map[string]struct {
+	kind string
+	size float64
+}{
+	"KCAL": {"energy", 1},
+	"KJ":   {"energy", 1 / 4.184},
+	"G":    {"mass", 1},
+	"MG":   {"mass", 1e-3},
+	"UG":   {"mass", 1e-6},
+}
+
+// usdaUnitFactor returns the factor to convert an amount between two units.
+func usdaUnitFactor(from string, to string) (float64, bool) {
+	source, ok := usdaUnits[strings.ToUpper(from)]
+	if !ok {
+		return 0, false
+	}
+	target := usdaUnits[to]
+	if source.kind != target.kind {
+		return 0, false
+	}
+	return source.size / target.size, true
+}
+
+// ImportUSDA imports the CSV files of a FoodData Central download from dir.
+// food.csv is required; nutrient.csv and food_nutrient.csv, food_portion.csv
+// and branded_food.csv are imported when present. Files may be gzipped.
+func (im *Importer) ImportUSDA(dir string) error {
+	foodPath, ok := usdaFile(dir, "food.csv")
+	if !ok {
+		return errors.New(dir + ": food.csv not found")
+	}
+	if err := im.importUSDAFoods(foodPath); err != nil {
+		return err
+	}
+
+	if path, ok := usdaFile(dir, "food_portion.csv"); ok {
+		units := map[string]string{}
+		if unitPath, ok := usdaFile(dir, "measure_unit.csv"); ok {
+			var err error
+			if units, err = loadUSDAMeasureUnits(unitPath); err != nil {
+				return err
+			}
+		}
+		if err := im.importUSDAPortions(path, units); err != nil {
+			return err
+		}
+	}
+
+	if path, ok := usdaFile(dir, "branded_food.csv"); ok {
+		if err := im.importUSDABranded(path); err != nil {
+			return err
+		}
+	}
+
+	nutrientPath, hasNutrients := usdaFile(dir, "nutrient.csv")
+	foodNutrientPath, hasFoodNutrients := usdaFile(dir, "food_nutrient.csv")
+	if hasNutrients && hasFoodNutrients {
+		nutrients, err := loadUSDANutrients(nutrientPath)
+		if err != nil {
+			return err
+		}
+		if err := im.importUSDAFoodNutrients(foodNutrientPath, nutrients); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// usdaFile finds a file of the download, possibly gzipped.
+func usdaFile(dir string, name string) (string, bool) {
+	for _, candidate := range []string{name, name + ".gz"} {
+		path := filepath.Join(dir, candidate)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// usdaCSV reads a FoodData Central CSV file, calling fn for every row after the
+// header. A malformed row is passed as nil.
+func usdaCSV(path string, fn func(row []string, index map[string]int, line int64) error) error {
+	input, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	reader := newCSVReader(input, ',')
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	index := columnIndex(header)
+
+	name := filepath.Base(path)
+	started := time.Now()
+	var line int64 = 1
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		line++
+		progress(name, line, started)
+		if malformedRow(err) {
+			row = nil
+		} else if err != nil {
+			return fmt.Errorf("%s: line %d: %w", path, line, err)
+		}
+		if err := fn(row, index, line); err != nil {
+			return err
+		}
+	}
+}
+
+func (im *Importer) importUSDAFoods(path string) error {
+	name := filepath.Base(path)
+	var batch []pendingFood
+	err := usdaCSV(path, func(row []string, index map[string]int, line int64) error {
+		if row == nil {
+			im.skip(name, line, "", "malformed_row")
+			return nil
+		}
+		id := field(row, index, "fdc_id")
+		description := field(row, index, "description")
+		switch {
+		case id == "":
+			im.skip(name, line, "", "missing_id")
+			return nil
+		case !usdaDataTypes[field(row, index, "data_type")]:
+			im.skip(name, line, id, "unsupported_data_type")
+			return nil
+		case description == "":
+			im.skip(name, line, id, "missing_name")
+			return nil
+		}
+
+		food := models.Food{Name: truncate(description, 255), Unit: models.FoodUnitGram, SourceID: &id}
+		batch = append(batch, pendingFood{food: food, file: name, line: line})
+		if len(batch) < im.batchSize() {
+			return nil
+		}
+		err := im.upsertFoods(models.FoodSourceUSDA, batch, []string{"name"})
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return im.upsertFoods(models.FoodSourceUSDA, batch, []string{"name"})
+}
+
+// importUSDABranded adds the brand, barcode, unit and serving of branded foods.
+func (im *Importer) importUSDABranded(path string) error {
+	name := filepath.Base(path)
+	columns := []string{"brand", "barcode", "unit"}
+	var batch []pendingFood
+	err := usdaCSV(path, func(row []string, index map[string]int, line int64) error {
+		if row == nil {
+			im.skip(name, line, "", "malformed_row")
+			return nil
+		}
+		id := field(row, index, "fdc_id")
+		if id == "" {
+			im.skip(name, line, "", "missing_id")
+			return nil
+		}
+
+		brand := field(row, index, "brand_name")
+		if brand == "" {
+			brand = field(row, index, "brand_owner")
+		}
+		food := models.Food{Brand: truncate(brand, 255), Unit: models.FoodUnitGram, SourceID: &id}
+		if code := strings.TrimSpace(field(row, index, "gtin_upc")); barcode.ValidBarcode(code) {
+			food.Barcode = &code
+		}
+
+		unit := strings.ToLower(field(row, index, "serving_size_unit"))
+		if unit == "ml" || unit == "mlt" {
+			food.Unit = models.FoodUnitMilliliter
+		}
+		if size, _ := strconv.ParseFloat(field(row, index, "serving_size"), 64); size > 0 {
+			servingName := field(row, index, "household_serving_fulltext")
+			if servingName == "" {
+				servingName = "1 serving"
+			}
+			food.Servings = []models.FoodServing{{
+				Name:     truncate(fmt.Sprintf("%s (%g %s)", servingName, size, food.Unit), 100),
+				Quantity: size,
+			}}
+		}
+
+		batch = append(batch, pendingFood{food: food, file: name, line: line})
+		if len(batch) < im.batchSize() {
+			return nil
+		}
+		err := im.updateFoods(models.FoodSourceUSDA, batch, columns)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return im.updateFoods(models.FoodSourceUSDA, batch, columns)
+}
+
+func loadUSDAMeasureUnits(path string) (map[string]string, error) {
+	units := map[string]string{}
+	err := usdaCSV(path, func(row []string, index map[string]int, line int64) error {
+		if row != nil {
+			units[field(row, index, "id")] = field(row, index, "name")
+		}
+		return nil
+	})
+	return units, err
+}
+
+// importUSDAPortions replaces the servings of USDA foods with the portions of
+// the download. A food's portions may be spread over the file, so the stored
+// servings are removed up front rather than per batch.
+func (im *Importer) importUSDAPortions(path string, units map[string]string) error {
+	name := filepath.Base(path)
+	err := im.DB.Where("food_id IN (?)", im.DB.Model(&models.Food{}).Select("id").Where("source = ?", models.FoodSourceUSDA)).
+		Unscoped().Delete(&models.FoodServing{}).Error
+	if err != nil {
+		return err
+	}
+
+	var batch []pendingFood
+	err = usdaCSV(path, func(row []string, index map[string]int, line int64) error {
+		if row == nil {
+			im.skip(name, line, "", "malformed_row")
+			return nil
+		}
+		id := field(row, index, "fdc_id")
+		grams, _ := strconv.ParseFloat(field(row, index, "gram_weight"), 64)
+		if id == "" || grams <= 0 {
+			im.skip(name, line, id, "missing_weight")
+			return nil
+		}
+
+		serving := models.FoodServing{Name: truncate(usdaPortionName(row, index, units), 100), Quantity: grams}
+		batch = append(batch, pendingFood{food: models.Food{SourceID: &id, Servings: []models.FoodServing{serving}}, file: name, line: line})
+		if len(batch) < im.batchSize() {
+			return nil
+		}
+		err := im.insertServings(batch)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return im.insertServings(batch)
+}
+
+// usdaPortionName describes a portion, e.g. "1 cup, chopped".
+func usdaPortionName(row []string, index map[string]int, units map[string]string) string {
+	if description := field(row, index, "portion_description"); description != "" && description != "Quantity not specified" {
+		return description
+	}
+
+	parts := []string{}
+	if amount := field(row, index, "amount"); amount != "" {
+		parts = append(parts, amount)
+	}
+	if unit := units[field(row, index, "measure_unit_id")]; unit != "" && unit != "undetermined" {
+		parts = append(parts, unit)
+	}
+	if modifier := field(row, index, "modifier"); modifier != "" {
+		parts = append(parts, modifier)
+	}
+	if len(parts) == 0 {
+		return "1 serving"
+	}
+	return strings.Join(parts, " ")
+}
+
+// insertServings adds servings to already imported foods.
+func (im *Importer) insertServings(batch []pendingFood) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	sourceIDs := make([]string, len(batch))
+	for i, pending := range batch {
+		sourceIDs[i] = *pending.food.SourceID
+	}
+
+	return im.DB.Transaction(func(tx *gorm.DB) error {
+		ids, err := foodIDs(tx, models.FoodSourceUSDA, sourceIDs)
+		if err != nil {
+			return err
+		}
+
+		var servings []models.FoodServing
+		for _, pending := range batch {
+			id, ok := ids[*pending.food.SourceID]
+			if !ok {
+				im.skip(pending.file, pending.line, *pending.food.SourceID, "unknown_food")
+				continue
+			}
+			for _, serving := range pending.food.Servings {
+				serving.FoodID = id
+				servings = append(servings, serving)
+			}
+		}
+		if len(servings) == 0 {
+			return nil
+		}
+		if err := tx.Create(&servings).Error; err != nil {
+			return err
+		}
+		im.Summary.Imported += len(servings)
+		return nil
+	})
+}
+
+// loadUSDANutrients reads nutrient.csv and returns the nutrients we store by nutrient ID.
+func loadUSDANutrients(path string) (map[string]usdaNutrient, error) {
+	tracked := map[string]usdaNutrient{}
+	err := usdaCSV(path, func(row []string, index map[string]int, line int64) error {
+		if row == nil {
+			return nil
+		}
+		nutrient, ok := usdaNutrientNumbers[field(row, index, "nutrient_nbr")]
+		if !ok {
+			return nil
+		}
+		factor, ok := usdaUnitFactor(field(row, index, "unit_name"), nutrient.unit)
+		if !ok {
+			return fmt.Errorf("%s: unexpected unit %q for nutrient %s", path, field(row, index, "unit_name"), field(row, index, "id"))
+		}
+		nutrient.factor = factor
+		tracked[field(row, index, "id")] = nutrient
+		return nil
+	})
+	return tracked, err
+}
+
+// usdaNutrientValues are the nutrients collected for one food.
+type usdaNutrientValues struct {
+	food       models.Food
+	columns    []string
+	energyRank int
+	line       int64
+}
+
+// set stores the amount of a nutrient, keeping the best energy value.
+func (v *usdaNutrientValues) set(nutrient usdaNutrient, amount float64) {
+	amount *= nutrient.factor
+	switch nutrient.column {
+	case "":
+		if v.food.NutrientsPer100 == nil {
+			v.food.NutrientsPer100 = models.NutrientAmounts{}
+			v.columns = append(v.columns, "nutrients_per100")
+		}
+		v.food.NutrientsPer100[nutrient.key] = amount
+		return
+	case "calories_per100":
+		if nutrient.rank > v.energyRank {
+			return
+		}
+		if !v.has(nutrient.column) {
+			v.columns = append(v.columns, nutrient.column)
+		}
+		v.energyRank = nutrient.rank
+		v.food.CaloriesPer100 = amount
+		return
+	case "proteins_per100":
+		v.food.ProteinsPer100 = amount
+	case "fats_per100":
+		v.food.FatsPer100 = amount
+	case "carbohydrates_per100":
+		v.food.CarbohydratesPer100 = amount
+	}
+	v.columns = append(v.columns, nutrient.column)
+}
+
+// has reports whether a column of the food was set.
+func (v *usdaNutrientValues) has(column string) bool {
+	for _, set := range v.columns {
+		if set == column {
+			return true
+		}
+	}
+	return false
+}
+
+// importUSDAFoodNutrients sets the nutrients of imported foods. food_nutrient.csv
+// is the largest file of the download with one row per food and nutrient; only
+// the rows of stored nutrients are kept, and they are written whenever enough
+// foods were collected. The file is grouped by food, so a food's values are
+// almost always written together. When rows of a food come back after it was
+// written, its other nutrients are merged with the stored ones and its energy
+// only replaced by a better value.
+func (im *Importer) importUSDAFoodNutrients(path string, tracked map[string]usdaNutrient) error {
+	name := filepath.Base(path)
+	pending := map[string]*usdaNutrientValues{}
+	written := map[string]int{} // energy rank written per food
+	lastID := ""
+
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		err := im.DB.Transaction(func(tx *gorm.DB) error {
+			if err := mergeStoredNutrients(tx, pending, written); err != nil {
+				return err
+			}
+			for id, values := range pending {
+				if !plausible(values.food) {
+					im.skip(name, values.line, id, "implausible_nutrients")
+					continue
+				}
+				values.food.NutrientsPer100 = values.food.NutrientsPer100.Rounded()
+				result := tx.Model(&models.Food{}).Where("source = ? AND source_id = ?", models.FoodSourceUSDA, id).
+					Select(append(values.columns, "updated_at")).Updates(values.food)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected > 0 {
+					im.Summary.Imported++
+					written[id] = values.energyRank
+				}
+			}
+			return nil
+		})
+		pending = map[string]*usdaNutrientValues{}
+		return err
+	}
+
+	err := usdaCSV(path, func(row []string, index map[string]int, line int64) error {
+		if row == nil {
+			im.skip(name, line, "", "malformed_row")
+			return nil
+		}
+		nutrient, ok := tracked[field(row, index, "nutrient_id")]
+		if !ok {
+			return nil
+		}
+		id := field(row, index, "fdc_id")
+		amount, err := strconv.ParseFloat(field(row, index, "amount"), 64)
+		if id == "" || err != nil {
+			im.skip(name, line, id, "invalid_amount")
+			return nil
+		}
+
+		// Only write between foods so their values go together
+		if id != lastID && len(pending) >= im.batchSize() {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		lastID = id
+
+		values, ok := pending[id]
+		if !ok {
+			values = &usdaNutrientValues{energyRank: len(usdaNutrientNumbers), line: line}
+			if rank, ok := written[id]; ok {
+				values.energyRank = rank
+			}
+			pending[id] = values
+		}
+		values.set(nutrient, amount)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// mergeStoredNutrients adds the stored other nutrients of pending foods that
+// were written before in the same import, so their earlier rows aren't lost.
+func mergeStoredNutrients(tx *gorm.DB, pending map[string]*usdaNutrientValues, written map[string]int) error {
+	var sourceIDs []string
+	for id, values := range pending {
+		if _, ok := written[id]; ok && values.has("nutrients_per100") {
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+	if len(sourceIDs) == 0 {
+		return nil
+	}
+
+	var stored []models.Food
+	err := tx.Select("source_id", "nutrients_per100").
+		Where("source = ? AND source_id IN ?", models.FoodSourceUSDA, sourceIDs).Find(&stored).Error
+	if err != nil {
+		return err
+	}
+	for _, food := range stored {
+		values := pending[*food.SourceID]
+		for key, amount := range food.NutrientsPer100 {
+			if _, ok := values.food.NutrientsPer100[key]; !ok {
+				values.food.NutrientsPer100[key] = amount
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package foodimport
+
+import (
+	"BAZ/Nutritracker/internal/fakedb"
+	"BAZ/Nutritracker/nutrients"
+	"database/sql/driver"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportUSDAFoodNutrientsKeepsRowsOfAFoodApart(t *testing.T) {
+	tracked := map[string]usdaNutrient{
+		"1008": {column: "calories_per100", unit: "KCAL", rank: 0, factor: 1},
+		"2047": {column: "calories_per100", unit: "KCAL", rank: 1, factor: 1},
+		"1079": {key: nutrients.Fiber, unit: "G", factor: 1},
+		"1093": {key: nutrients.Sodium, unit: "MG", factor: 1},
+	}
+	// Food 100 comes back after food 200 was read, so its first rows were written already
+	rows := "id,fdc_id,nutrient_id,amount\n" +
+		"1,100,1079,2.5\n" +
+		"2,100,1008,50\n" +
+		"3,200,1079,1\n" +
+		"4,100,1093,120\n" +
+		"5,100,2047,60\n"
+	path := filepath.Join(t.TempDir(), "food_nutrient.csv")
+	if err := os.WriteFile(path, []byte(rows), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, gormDB, err := fakedb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.On("foods", []string{"source_id", "nutrients_per100"}, []driver.Value{"100", `{"fiber":2.5}`})
+
+	im := &Importer{DB: gormDB, BatchSize: 1}
+	if err := im.importUSDAFoodNutrients(path, tracked); err != nil {
+		t.Fatal(err)
+	}
+	if unanswered := db.Unanswered(); len(unanswered) > 0 {
+		t.Fatalf("unanswered queries: %v", unanswered)
+	}
+
+	updates := db.ExecutedOn("foods")
+	if len(updates) != 3 {
+		t.Fatalf("%d foods updated, want 3: %v", len(updates), updates)
+	}
+	last := updates[2]
+	if strings.Contains(last.SQL, "calories_per100") {
+		t.Errorf("a worse energy value replaced the written one: %s", last.SQL)
+	}
+	var stored map[string]float64
+	for _, arg := range last.Args {
+		if text, ok := arg.(string); ok && strings.HasPrefix(text, "{") {
+			if err := json.Unmarshal([]byte(text), &stored); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	want := map[string]float64{nutrients.Fiber: 2.5, nutrients.Sodium: 120}
+	if len(stored) != len(want) || stored[nutrients.Fiber] != want[nutrients.Fiber] || stored[nutrients.Sodium] != want[nutrients.Sodium] {
+		t.Errorf("nutrients_per100 = %v, want %v", stored, want)
+	}
+}
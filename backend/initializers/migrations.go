@@ -0,0 +1,212 @@
+package initializers
+
+import (
+	"BAZ/Nutritracker/models"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// legacyDateLayouts are the formats meal dates were stored in while they were
+// free text.
+var legacyDateLayouts = []string{
+	models.DateLayout,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-1-2",
+	"2006/01/02",
+	"02-01-2006",
+	"2-1-2006",
+}
+
+// legacyMealMoment is a row with a meal date and time stored as text.
+type legacyMealMoment struct {
+	ID        uint
+	UserID    uint
+	MealDate  *string
+	MealTime  *string
+	CreatedAt time.Time
+}
+
+// migrateMealMoments rewrites the text meal dates and times of nutrilogs and
+// saved meal items to YYYY-MM-DD and HH:MM, so AutoMigrate can turn the
+// columns into DATE and TIME afterwards. Dates that can't be read fall back
+// to the day the row was created in the user's timezone, times that can't be
+// read are cleared.
+func migrateMealMoments(db *gorm.DB) error {
+	if isTextColumn(db, "nutrilogs", "meal_date") {
+		log.Println("Migrating meal dates and times of nutrilogs...")
+		var users []models.User
+		if err := db.Select("id", "timezone").Find(&users).Error; err != nil {
+			return err
+		}
+		locations := make(map[uint]*time.Location, len(users))
+		for _, user := range users {
+			locations[user.ID] = user.Location()
+		}
+
+		var rows []legacyMealMoment
+		err := db.Table("nutrilogs").Select("id", "user_id", "meal_date", "meal_time", "created_at").
+			FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+				for _, row := range rows {
+					err := db.Table("nutrilogs").Where("id = ?", row.ID).Updates(map[string]interface{}{
+						"meal_date": legacyMealDate(row.MealDate, row.CreatedAt, locations[row.UserID]),
+						"meal_time": legacyMealTime(row.MealTime),
+					}).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if isTextColumn(db, "saved_meal_items", "meal_time") {
+		log.Println("Migrating meal times of saved meals...")
+		var rows []legacyMealMoment
+		err := db.Table("saved_meal_items").Select("id", "meal_time").
+			FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+				for _, row := range rows {
+					err := db.Table("saved_meal_items").Where("id = ?", row.ID).
+						Update("meal_time", legacyMealTime(row.MealTime)).Error
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTextColumn reports whether a column exists and still has a text type.
+func isTextColumn(db *gorm.DB, table string, column string) bool {
+	if !db.Migrator().HasTable(table) {
+		return false
+	}
+	columnTypes, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return false
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() == column {
+			typeName := strings.ToLower(columnType.DatabaseTypeName())
+			return strings.Contains(typeName, "text") || strings.Contains(typeName, "char")
+		}
+	}
+	return false
+}
+
+func legacyMealDate(raw *string, createdAt time.Time, loc *time.Location) models.Date {
+	if raw != nil {
+		value := strings.TrimSpace(*raw)
+		for _, layout := range legacyDateLayouts {
+			if day, err := time.Parse(layout, value); err == nil {
+				return models.DateOf(day)
+			}
+		}
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return models.DateOf(createdAt.In(loc))
+}
+
+func legacyMealTime(raw *string) models.TimeOfDay {
+	if raw == nil {
+		return ""
+	}
+	timeOfDay, err := models.ParseTimeOfDay(strings.TrimSpace(*raw))
+	if err != nil {
+		return ""
+	}
+	return timeOfDay
+}
+
+// migrateStatsLastLogDate clears the empty last log dates of users that never
+// logged a meal, so AutoMigrate can turn the text column into a DATE afterwards.
+func migrateStatsLastLogDate(db *gorm.DB) error {
+	if !isTextColumn(db, "stats", "last_log_date") {
+		return nil
+	}
+	log.Println("Migrating last log dates of stats...")
+	return db.Table("stats").Where("last_log_date = ?", "").Update("last_log_date", nil).Error
+}
+
+// migrateGoalVersions gives the nutrition goals created before goals were
+// versioned their effective dates. Each goal applied from the day it started
+// until the next goal of the user started. Changes made in place before, such
+// as automatic increases, can't be recovered.
+func migrateGoalVersions(db *gorm.DB) error {
+	log.Println("Migrating nutrition goals to versions...")
+	var users []models.User
+	if err := db.Select("id", "timezone").Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		var goals []models.NutritionGoal
+		if err := db.Where("user_id = ?", user.ID).Order("start_date, id").Find(&goals).Error; err != nil {
+			return err
+		}
+
+		for i, goal := range goals {
+			started := goal.StartDate
+			if started.IsZero() {
+				started = goal.CreatedAt
+			}
+			updates := map[string]interface{}{
+				"effective_from": models.DateOf(started.In(user.Location())),
+				"effective_to":   nil,
+				"previous_id":    nil,
+			}
+			if i > 0 {
+				updates["previous_id"] = goals[i-1].ID
+			}
+			if !goal.IsActive {
+				ended := goal.UpdatedAt
+				if i+1 < len(goals) {
+					ended = goals[i+1].StartDate
+					if ended.IsZero() {
+						ended = goals[i+1].CreatedAt
+					}
+				}
+				updates["effective_to"] = models.DateOf(ended.In(user.Location()))
+			}
+			if err := db.Model(&models.NutritionGoal{}).Where("id = ?", goal.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateBuddyPairs fills the pair columns of existing buddy relations before
+// AutoMigrate adds their unique index. Of duplicate relations between the same
+// two users only the oldest is kept.
+func migrateBuddyPairs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Buddy{}) || db.Migrator().HasColumn(&models.Buddy{}, "PairLowID") {
+		return nil
+	}
+	log.Println("Migrating buddy pairs...")
+	if err := db.Migrator().AddColumn(&models.Buddy{}, "PairLowID"); err != nil {
+		return err
+	}
+	if err := db.Migrator().AddColumn(&models.Buddy{}, "PairHighID"); err != nil {
+		return err
+	}
+	err := db.Exec("UPDATE buddies SET pair_low_id = LEAST(requester_id, addressee_id), pair_high_id = GREATEST(requester_id, addressee_id)").Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("DELETE newer FROM buddies newer JOIN buddies older " +
+		"ON newer.pair_low_id = older.pair_low_id AND newer.pair_high_id = older.pair_high_id AND newer.id > older.id").Error
+}
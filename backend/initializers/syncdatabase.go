@@ -1,7 +1,10 @@
 package initializers
 
 import (
+	"BAZ/Nutritracker/achievements"
 	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"BAZ/Nutritracker/stats"
 	"log"
 )
 
@@ -11,9 +14,92 @@ func SyncDatabase() {
 	if DB != nil && DB.Config != nil {
 		log.Println("Syncing database schema...")
 		DB.AutoMigrate(&models.User{})
+		DB.AutoMigrate(&models.UserSession{})
+		DB.AutoMigrate(&models.Nutrient{})
+		if err := nutrients.SyncCatalog(DB); err != nil {
+			log.Println("Warning: failed to sync nutrient catalog:", err)
+		}
+		DB.AutoMigrate(&models.Food{})
+		DB.AutoMigrate(&models.FoodServing{})
+		DB.AutoMigrate(&models.BarcodeLookupMiss{})
+		DB.AutoMigrate(&models.Recipe{})
+		DB.AutoMigrate(&models.RecipeIngredient{})
+		if err := migrateMealMoments(DB); err != nil {
+			// AutoMigrate would turn the unconverted text into DATE and TIME
+			// columns and lose the meal dates and times
+			log.Println("Error: failed to migrate meal dates and times, stopping the database sync:", err)
+			return
+		}
 		DB.AutoMigrate(&models.Nutrilog{})
+		DB.AutoMigrate(&models.SavedMeal{})
+		DB.AutoMigrate(&models.SavedMealItem{})
+		newGoalVersions := DB.Migrator().HasTable(&models.NutritionGoal{}) &&
+			!DB.Migrator().HasColumn(&models.NutritionGoal{}, "EffectiveFrom")
 		DB.AutoMigrate(&models.NutritionGoal{})
+		if newGoalVersions {
+			if err := migrateGoalVersions(DB); err != nil {
+				log.Println("Warning: failed to migrate nutrition goal versions:", err)
+			}
+		}
+		DB.AutoMigrate(&models.GoalProgressionPolicy{})
+		DB.AutoMigrate(&models.BodyMetrics{})
+		DB.AutoMigrate(&models.Measurement{})
+		DB.AutoMigrate(&models.GoalChange{})
+		DB.AutoMigrate(&models.MotivationalMessage{})
+		DB.AutoMigrate(&models.MessageTemplate{})
+		DB.AutoMigrate(&models.Guardian{})
+		DB.AutoMigrate(&models.GuardianInvitation{})
+		DB.AutoMigrate(&models.Cases{})
+		DB.AutoMigrate(&models.CaseEvent{})
+		if err := migrateStatsLastLogDate(DB); err != nil {
+			log.Println("Warning: failed to migrate last log dates:", err)
+		}
+		DB.AutoMigrate(&models.Stats{})
+		newDailySummaries := newGoalVersions || !DB.Migrator().HasTable(&models.DailySummary{}) ||
+			!DB.Migrator().HasColumn(&models.DailySummary{}, "GoalStatuses")
+		DB.AutoMigrate(&models.DailySummary{})
+		if newDailySummaries {
+			backfillDailySummaries()
+		}
+		DB.AutoMigrate(&models.Achievement{})
+		DB.AutoMigrate(&models.Accomplished_achievements{})
+		if err := achievements.SyncCatalog(DB); err != nil {
+			log.Println("Warning: failed to sync achievement catalog:", err)
+		}
+		newWeightLossRule := DB.Migrator().HasTable(&models.CaseRuleSettings{}) &&
+			!DB.Migrator().HasColumn(&models.CaseRuleSettings{}, "WeightLossDays")
+		DB.AutoMigrate(&models.CaseRuleSettings{})
+		if newWeightLossRule {
+			// Patients with their own thresholds get the default weight loss rule
+			defaults := models.DefaultCaseRuleSettings(0)
+			DB.Model(&models.CaseRuleSettings{}).Where("1 = 1").Updates(map[string]interface{}{
+				"weight_loss_percentage": defaults.WeightLossPercentage,
+				"weight_loss_days":       defaults.WeightLossDays,
+			})
+		}
+		DB.AutoMigrate(&models.Notification{})
+		if err := migrateBuddyPairs(DB); err != nil {
+			log.Println("Warning: failed to migrate buddy pairs:", err)
+		}
+		DB.AutoMigrate(&models.Buddy{})
 	} else {
 		log.Println("Skipping database synchronization due to missing connection.")
 	}
 }
+
+// backfillDailySummaries builds the daily summaries and streaks of all users
+// the first time the summary table is created, or rebuilds them when it gains
+// a column or goals gain their history.
+func backfillDailySummaries() {
+	log.Println("Backfilling daily summaries...")
+	var userIDs []uint
+	if err := DB.Model(&models.User{}).Pluck("id", &userIDs).Error; err != nil {
+		log.Println("Warning: failed to backfill daily summaries:", err)
+		return
+	}
+	for _, userID := range userIDs {
+		if err := stats.Rebuild(DB, userID); err != nil {
+			log.Printf("Warning: failed to backfill daily summaries of user %d: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package fakedb is a database/sql driver for tests that answers queries with
+// canned rows, so code using gorm can be tested without a MySQL server. A
+// query on a table without a canned answer fails, so every table a test reads
+// has to be answered, if only with no rows. Statements that change data
+// succeed and are recorded with their arguments for the test to check.
+package fakedb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// DB holds the canned answers and the statements that were run.
+type DB struct {
+	mu         sync.Mutex
+	results    []result
+	queries    []Query
+	unanswered []Query
+	execs      []Query
+}
+
+// Query is a statement that was run with its arguments.
+type Query struct {
+	SQL  string
+	Args []driver.Value
+}
+
+// result answers the queries whose SQL contains match.
+type result struct {
+	match   string
+	columns []string
+	rows    [][]driver.Value
+}
+
+// Open returns a gorm connection to a new fake database.
+func Open() (*DB, *gorm.DB, error) {
+	db := &DB{}
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      sql.OpenDB(db),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	return db, gormDB, err
+}
+
+// On answers queries on a table with the given rows, none for an empty
+// table. The first answer matching a query is used.
+func (db *DB) On(table string, columns []string, rows ...[]driver.Value) {
+	db.OnQuery("FROM `"+table+"`", columns, rows...)
+}
+
+// OnQuery answers the queries containing match with the given rows, for
+// queries that need another answer than the rest of their table, like counts.
+func (db *DB) OnQuery(match string, columns []string, rows ...[]driver.Value) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.results = append(db.results, result{match: match, columns: columns, rows: rows})
+}
+
+// Queries returns the queries that read data, in order.
+func (db *DB) Queries() []Query {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Query{}, db.queries...)
+}
+
+// Unanswered returns the queries that failed for lack of a canned answer.
+func (db *DB) Unanswered() []Query {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Query{}, db.unanswered...)
+}
+
+// Executed returns the statements that changed data, in order.
+func (db *DB) Executed() []Query {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return append([]Query{}, db.execs...)
+}
+
+// ExecutedOn returns the statements that changed data of a table, in order.
+func (db *DB) ExecutedOn(table string) []Query {
+	var statements []Query
+	for _, statement := range db.Executed() {
+		if strings.Contains(statement.SQL, "`"+table+"`") {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+func (db *DB) Connect(ctx context.Context) (driver.Conn, error) { return &conn{db: db}, nil }
+func (db *DB) Driver() driver.Driver                            { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("open the fake database with fakedb.Open")
+}
+
+type conn struct{ db *DB }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+func (c *conn) Close() error              { return nil }
+func (c *conn) Begin() (driver.Tx, error) { return tx{}, nil }
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.queries = append(c.db.queries, Query{SQL: query, Args: values(args)})
+	for _, result := range c.db.results {
+		if strings.Contains(query, result.match) {
+			return &rows{columns: result.columns, rows: result.rows}, nil
+		}
+	}
+	c.db.unanswered = append(c.db.unanswered, Query{SQL: query, Args: values(args)})
+	return nil, fmt.Errorf("fakedb: no answer for %s", query)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.execs = append(c.db.execs, Query{SQL: query, Args: values(args)})
+	return execResult{}, nil
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type execResult struct{}
+
+func (execResult) LastInsertId() (int64, error) { return 1, nil }
+func (execResult) RowsAffected() (int64, error) { return 1, nil }
+
+type rows struct {
+	columns []string
+	rows    [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
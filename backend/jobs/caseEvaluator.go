@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/measurements"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultCaseEvaluationInterval = time.Hour
+
+// StartCaseEvaluator periodically evaluates the case rules for every patient.
+// The interval can be changed with the CASE_EVALUATION_INTERVAL env variable.
+func StartCaseEvaluator() {
+	interval := defaultCaseEvaluationInterval
+	if value := os.Getenv("CASE_EVALUATION_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("Invalid CASE_EVALUATION_INTERVAL %q, using %s", value, interval)
+		} else {
+			interval = parsed
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			EvaluateAllPatients(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+// EvaluateAllPatients runs the case rules for every patient.
+func EvaluateAllPatients(now time.Time) {
+	if initializers.DB == nil {
+		return
+	}
+
+	var patients []models.User
+	if err := initializers.DB.Where("role = ?", models.RolePatient).Find(&patients).Error; err != nil {
+		log.Println("Error fetching patients for case evaluation:", err)
+		return
+	}
+
+	for _, patient := range patients {
+		if err := EvaluatePatient(patient, now); err != nil {
+			log.Printf("Error evaluating case rules for user %d: %v", patient.ID, err)
+		}
+	}
+}
+
+// EvaluatePatient opens a case for every rule the patient's recent nutrilogs
+// and weight break, unless a case for that rule is already open or was closed
+// during the days the rule looked at.
+func EvaluatePatient(patient models.User, now time.Time) error {
+	settings := models.DefaultCaseRuleSettings(patient.ID)
+	initializers.DB.Where("user_id = ?", patient.ID).First(&settings)
+	if !settings.Enabled {
+		return nil
+	}
+
+	// Weight loss over the window, from the smoothed trend so a single low
+	// weighing doesn't open a case
+	var weightLost *weightChange
+	if settings.WeightLossDays > 0 && settings.WeightLossPercentage > 0 {
+		entries, err := measurements.Series(initializers.DB, patient.ID, models.MeasurementWeight)
+		if err != nil {
+			return err
+		}
+		points := measurements.Trend(entries)
+		from := now.AddDate(0, 0, -settings.WeightLossDays)
+		if lost, ok := weightLoss(points, from, now); ok {
+			weightLost = &weightChange{Lost: lost, Since: models.DateOf(from.In(patient.Location()))}
+		}
+	}
+
+	window := settings.MissedMealDays
+	if settings.LowCaloriesDays > window {
+		window = settings.LowCaloriesDays
+	}
+	if window <= 0 {
+		return openCases(patient, evaluateCaseRules(settings, nil, 0, weightLost))
+	}
+
+	// Only look at completed days of the patient, today can still be logged
+	loc := patient.Location()
+	today := models.DateOf(now.In(loc))
+	dates := make([]models.Date, window)
+	for i := 0; i < window; i++ {
+		dates[i] = today.AddDays(i - window)
+	}
+
+	// Don't flag days before the patient started using the app
+	if models.DateOf(patient.CreatedAt.In(loc)) > dates[0] {
+		return openCases(patient, evaluateCaseRules(settings, nil, 0, weightLost))
+	}
+
+	var nutrilogs []models.Nutrilog
+	err := initializers.DB.
+		Where("user_id = ? AND meal_date >= ? AND meal_date <= ?", patient.ID, dates[0], dates[len(dates)-1]).
+		Find(&nutrilogs).Error
+	if err != nil {
+		return err
+	}
+
+	// The goal in effect on the last evaluated day, not a later one
+	var caloriesGoal int
+	nutritionGoal, err := stats.GoalOn(initializers.DB, patient.ID, dates[len(dates)-1])
+	if err != nil {
+		return err
+	}
+	if nutritionGoal != nil {
+		caloriesGoal = nutritionGoal.CaloriesGoal
+	}
+
+	days := buildDailyIntake(dates, nutrilogs)
+	return openCases(patient, evaluateCaseRules(settings, days, caloriesGoal, weightLost))
+}
+
+// lastClosedCase returns the event that last resolved or dismissed a case of
+// the rule for a user, nil if there is none.
+func lastClosedCase(userID uint, rule string) (*models.CaseEvent, error) {
+	var event models.CaseEvent
+	err := initializers.DB.
+		Joins("JOIN cases ON cases.id = case_events.case_id").
+		Where("cases.user_id = ? AND cases.rule = ? AND case_events.to_status IN ?",
+			userID, rule, []string{models.CaseStatusResolved, models.CaseStatusDismissed}).
+		Order("case_events.id DESC").
+		Take(&event).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// openCases opens a case for each finding.
+func openCases(patient models.User, findings []caseFinding) error {
+	for _, finding := range findings {
+		if err := openCase(patient, finding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openCase creates the case for a finding and notifies the patient's guardians.
+// A resolved or dismissed case of the rule is only followed by a new one once
+// all evidence of the finding is from after the day it was closed, so the days
+// that led to the closed case don't open it again.
+func openCase(patient models.User, finding caseFinding) error {
+	var existing int64
+	err := initializers.DB.Model(&models.Cases{}).
+		Where("user_id = ? AND rule = ? AND status IN ?", patient.ID, finding.Rule, models.ActiveCaseStatuses).
+		Count(&existing).Error
+	if err != nil || existing > 0 {
+		return err
+	}
+
+	closed, err := lastClosedCase(patient.ID, finding.Rule)
+	if err != nil {
+		return err
+	}
+	if closed != nil && finding.Since <= models.DateOf(closed.CreatedAt.In(patient.Location())) {
+		return nil
+	}
+
+	tx := initializers.DB.Begin()
+
+	newCase := models.Cases{
+		Status: models.CaseStatusOpen,
+		Reason: finding.Reason,
+		Rule:   finding.Rule,
+		UserID: patient.ID,
+	}
+	if err := tx.Create(&newCase).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	event := models.CaseEvent{
+		CaseID:   newCase.ID,
+		Type:     models.CaseEventCreated,
+		ToStatus: newCase.Status,
+		Comment:  finding.Reason,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := stats.RefreshCases(tx, patient.ID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var links []models.Guardian
+	if err := tx.Where("patient_id = ? AND revoked_at IS NULL", patient.ID).Find(&links).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, link := range links {
+		notification := models.Notification{
+			UserID:  link.GuardianID,
+			Type:    "case_opened",
+			Message: fmt.Sprintf("%s needs attention: %s", patient.FirstName, finding.Reason),
+			CaseID:  &newCase.ID,
+		}
+		if err := tx.Create(&notification).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"BAZ/Nutritracker/internal/fakedb"
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestEvaluateCaseRulesSince(t *testing.T) {
+	settings := models.CaseRuleSettings{
+		Enabled:               true,
+		MissedMealDays:        2,
+		LowCaloriesDays:       3,
+		LowCaloriesPercentage: 50,
+		WeightLossDays:        30,
+		WeightLossPercentage:  5,
+	}
+	dates := []models.Date{"2026-10-12", "2026-10-13", "2026-10-14", "2026-10-15"}
+	days := buildDailyIntake(dates, nil)
+	weightLost := &weightChange{Lost: 6, Since: "2026-09-16"}
+
+	want := map[string]models.Date{
+		RuleMissedMeals + ":breakfast": "2026-10-14",
+		RuleMissedMeals + ":lunch":     "2026-10-14",
+		RuleMissedMeals + ":dinner":    "2026-10-14",
+		RuleLowCalories:                "2026-10-13",
+		RuleWeightLoss:                 "2026-09-16",
+	}
+
+	findings := evaluateCaseRules(settings, days, 2000, weightLost)
+	if len(findings) != len(want) {
+		t.Fatalf("evaluateCaseRules() = %d findings, want %d", len(findings), len(want))
+	}
+	for _, finding := range findings {
+		if finding.Since != want[finding.Rule] {
+			t.Errorf("%s since %s, want %s", finding.Rule, finding.Since, want[finding.Rule])
+		}
+	}
+}
+
+func TestOpenCaseAfterClosedCase(t *testing.T) {
+	finding := caseFinding{Rule: RuleLowCalories, Reason: "Calories below 50% of the goal for 3 days", Since: "2026-10-13"}
+
+	tests := []struct {
+		name     string
+		closedAt *time.Time
+		wantOpen bool
+	}{
+		{"never closed", nil, true},
+		{"closed before the evidence", timeAt("2026-10-12T18:00:00Z"), true},
+		{"closed on the first day of the evidence", timeAt("2026-10-13T08:00:00Z"), false},
+		{"closed during the evidence", timeAt("2026-10-15T08:00:00Z"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, gormDB, err := fakedb.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			previous := initializers.DB
+			initializers.DB = gormDB
+			t.Cleanup(func() { initializers.DB = previous })
+
+			if tt.closedAt != nil {
+				db.On("case_events",
+					[]string{"id", "case_id", "type", "from_status", "to_status", "created_at"},
+					[]driver.Value{int64(4), int64(2), models.CaseEventStatusChanged, models.CaseStatusInProgress, models.CaseStatusResolved, *tt.closedAt},
+				)
+			}
+			db.On("case_events", []string{"id"})
+			db.On("cases", []string{"count"}, []driver.Value{int64(0)})
+			db.On("guardians", []string{"id"})
+
+			patient := models.User{Model: gorm.Model{ID: 1}, Timezone: "UTC"}
+			if err := openCase(patient, finding); err != nil {
+				t.Fatal(err)
+			}
+
+			opened := false
+			for _, statement := range db.ExecutedOn("cases") {
+				opened = opened || strings.HasPrefix(statement.SQL, "INSERT")
+			}
+			if opened != tt.wantOpen {
+				t.Errorf("case opened = %v, want %v", opened, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func timeAt(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return &t
+}
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"BAZ/Nutritracker/measurements"
+	"BAZ/Nutritracker/models"
+	"fmt"
+	"time"
+)
+
+const (
+	RuleMissedMeals = "missed_meals"
+	RuleLowCalories = "low_calories"
+	RuleWeightLoss  = "weight_loss"
+)
+
+// trackedMealTypes are the meals a patient is expected to log every day.
+var trackedMealTypes = []string{"breakfast", "lunch", "dinner"}
+
+// dailyIntake is what a patient logged on one day.
+type dailyIntake struct {
+	Date      models.Date
+	MealTypes map[string]bool
+	Calories  float64
+}
+
+// caseFinding is a rule that fired and should lead to an open case. Since is
+// the first day of the evidence.
+type caseFinding struct {
+	Rule   string
+	Reason string
+	Since  models.Date
+}
+
+// weightChange is the share of the weight trend, in percent, lost since a day.
+type weightChange struct {
+	Lost  float64
+	Since models.Date
+}
+
+// buildDailyIntake groups nutrilogs per day for the given dates, in order.
+// Days without any nutrilog are included as empty days.
+func buildDailyIntake(dates []models.Date, nutrilogs []models.Nutrilog) []dailyIntake {
+	byDate := make(map[models.Date]*dailyIntake, len(dates))
+	days := make([]dailyIntake, len(dates))
+	for i, date := range dates {
+		days[i] = dailyIntake{Date: date, MealTypes: map[string]bool{}}
+		byDate[date] = &days[i]
+	}
+
+	for _, log := range nutrilogs {
+		day, ok := byDate[log.MealDate]
+		if !ok {
+			continue
+		}
+		day.MealTypes[log.MealType] = true
+		day.Calories += log.Calories
+	}
+	return days
+}
+
+// missedMealTypes returns the tracked meal types that were not logged on any
+// of the given days.
+func missedMealTypes(days []dailyIntake) []string {
+	var missed []string
+	for _, mealType := range trackedMealTypes {
+		logged := false
+		for _, day := range days {
+			if day.MealTypes[mealType] {
+				logged = true
+				break
+			}
+		}
+		if !logged {
+			missed = append(missed, mealType)
+		}
+	}
+	return missed
+}
+
+// allBelowCalories reports whether every day stayed under percentage% of the calorie goal.
+func allBelowCalories(days []dailyIntake, caloriesGoal int, percentage int) bool {
+	if len(days) == 0 || caloriesGoal <= 0 {
+		return false
+	}
+	limit := float64(caloriesGoal) * float64(percentage) / 100
+	for _, day := range days {
+		if day.Calories >= limit {
+			return false
+		}
+	}
+	return true
+}
+
+// weightLoss returns the share of the weight trend, in percent, lost between
+// two moments. Returns false when there is no trend at the start.
+func weightLoss(points []measurements.TrendPoint, from time.Time, to time.Time) (float64, bool) {
+	start, ok := measurements.TrendAt(points, from)
+	if !ok || start <= 0 {
+		return 0, false
+	}
+	end, _ := measurements.TrendAt(points, to)
+	return (start - end) / start * 100, true
+}
+
+// evaluateCaseRules runs every rule over the most recent completed days, which
+// must be ordered oldest first. weightLost is the weight lost over the weight
+// loss window, nil when it isn't known.
+func evaluateCaseRules(settings models.CaseRuleSettings, days []dailyIntake, caloriesGoal int, weightLost *weightChange) []caseFinding {
+	var findings []caseFinding
+
+	if n := settings.MissedMealDays; n > 0 && len(days) >= n {
+		for _, mealType := range missedMealTypes(days[len(days)-n:]) {
+			findings = append(findings, caseFinding{
+				Rule:   RuleMissedMeals + ":" + mealType,
+				Reason: fmt.Sprintf("No %s logged for %d days", mealType, n),
+				Since:  days[len(days)-n].Date,
+			})
+		}
+	}
+
+	if n := settings.LowCaloriesDays; n > 0 && len(days) >= n {
+		if allBelowCalories(days[len(days)-n:], caloriesGoal, settings.LowCaloriesPercentage) {
+			findings = append(findings, caseFinding{
+				Rule:   RuleLowCalories,
+				Reason: fmt.Sprintf("Calories below %d%% of the goal for %d days", settings.LowCaloriesPercentage, n),
+				Since:  days[len(days)-n].Date,
+			})
+		}
+	}
+
+	if weightLost != nil && settings.WeightLossPercentage > 0 && weightLost.Lost >= float64(settings.WeightLossPercentage) {
+		findings = append(findings, caseFinding{
+			Rule:   RuleWeightLoss,
+			Reason: fmt.Sprintf("Lost %.1f%% of their weight in %d days", weightLost.Lost, settings.WeightLossDays),
+			Since:  weightLost.Since,
+		})
+	}
+
+	return findings
+}
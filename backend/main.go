@@ -2,9 +2,11 @@ package main
 
 import (
 	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/jobs"
 	"BAZ/Nutritracker/routes"
 	"fmt"
 	"time"
+	_ "time/tzdata" // timezones of users, also on hosts without a zoneinfo database
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -38,8 +40,10 @@ func main() {
 	v1 := router.Group("/api/v1")
 	{
 		routes.Routes(v1.Group("/user"))
-		// routes.AdminRoutes(v1.Group("/admin"))
+		routes.AdminRoutes(v1.Group("/admin"))
 	}
 
+	jobs.StartCaseEvaluator()
+
 	router.Run()
 }
@@ -0,0 +1,96 @@
+package measurements
+
+import (
+	"BAZ/Nutritracker/models"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DailySmoothing is the share of the gap between the trend and a new
+// measurement the trend closes per day. A tenth per day evens out the day to
+// day swings of body weight while following real changes within weeks.
+const DailySmoothing = 0.1
+
+// rateWindow is how far back the weekly rate of change looks.
+const rateWindow = 28 * 24 * time.Hour
+
+// TrendPoint is a measurement with the smoothed trend at that moment.
+type TrendPoint struct {
+	MeasuredAt time.Time `json:"measured_at"`
+	Value      float64   `json:"value"`
+	Trend      float64   `json:"trend"`
+}
+
+// Trend smooths measurements sorted by time with an exponential moving
+// average. The weight of a measurement grows with the time since the previous
+// one, so irregular logging doesn't skew the trend.
+func Trend(entries []models.Measurement) []TrendPoint {
+	points := make([]TrendPoint, len(entries))
+	for i, entry := range entries {
+		trend := entry.Value
+		if i > 0 {
+			previous := points[i-1]
+			days := entry.MeasuredAt.Sub(previous.MeasuredAt).Hours() / 24
+			alpha := 1 - math.Pow(1-DailySmoothing, math.Max(days, 0))
+			trend = previous.Trend + alpha*(entry.Value-previous.Trend)
+		}
+		points[i] = TrendPoint{
+			MeasuredAt: entry.MeasuredAt,
+			Value:      entry.Value,
+			Trend:      math.Round(trend*100) / 100,
+		}
+	}
+	return points
+}
+
+// TrendAt returns the trend at a moment, from the last point at or before it.
+func TrendAt(points []TrendPoint, at time.Time) (float64, bool) {
+	for i := len(points) - 1; i >= 0; i-- {
+		if !points[i].MeasuredAt.After(at) {
+			return points[i].Trend, true
+		}
+	}
+	return 0, false
+}
+
+// WeeklyRate returns the change of the trend per week over the last four
+// weeks of points. It is nil when the points span less than a day.
+func WeeklyRate(points []TrendPoint) *float64 {
+	if len(points) < 2 {
+		return nil
+	}
+	last := points[len(points)-1]
+	first := points[0]
+	for _, point := range points {
+		if !point.MeasuredAt.Before(last.MeasuredAt.Add(-rateWindow)) {
+			first = point
+			break
+		}
+	}
+	days := last.MeasuredAt.Sub(first.MeasuredAt).Hours() / 24
+	if days < 1 {
+		return nil
+	}
+	rate := math.Round((last.Trend-first.Trend)/days*7*100) / 100
+	return &rate
+}
+
+// Series returns the measurements of one kind of a user, oldest first.
+func Series(tx *gorm.DB, userID uint, kind string) ([]models.Measurement, error) {
+	var entries []models.Measurement
+	err := tx.Where("user_id = ? AND type = ?", userID, kind).Order("measured_at, id").Find(&entries).Error
+	return entries, err
+}
+
+// CurrentTrend returns the latest trend of one kind of measurement of a user.
+// Returns false when the user has no such measurement.
+func CurrentTrend(tx *gorm.DB, userID uint, kind string) (float64, bool, error) {
+	entries, err := Series(tx, userID, kind)
+	if err != nil || len(entries) == 0 {
+		return 0, false, err
+	}
+	points := Trend(entries)
+	return points[len(points)-1].Trend, true, nil
+}
@@ -0,0 +1,137 @@
+package measurements
+
+import (
+	"BAZ/Nutritracker/models"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+
+// day returns the moment days after the start, fractions being parts of a day.
+func day(days float64) time.Time {
+	return start.Add(time.Duration(days * 24 * float64(time.Hour)))
+}
+
+// measurements returns weights measured on the given days after the start.
+func measurements(values map[float64]float64, days ...float64) []models.Measurement {
+	entries := make([]models.Measurement, len(days))
+	for i, d := range days {
+		entries[i] = models.Measurement{Type: models.MeasurementWeight, Value: values[d], MeasuredAt: day(d)}
+	}
+	return entries
+}
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []models.Measurement
+		want    []float64
+	}{
+		{"no measurements", nil, []float64{}},
+		{"first measurement", measurements(map[float64]float64{0: 80}, 0), []float64{80}},
+		{"daily", measurements(map[float64]float64{0: 80, 1: 70, 2: 70}, 0, 1, 2), []float64{80, 79, 78.1}},
+		// A gap of two days moves the trend as far as two daily measurements of the same value
+		{"gap of two days", measurements(map[float64]float64{0: 80, 2: 70}, 0, 2), []float64{80, 78.1}},
+		{"gap of three days", measurements(map[float64]float64{0: 80, 1: 81, 4: 79}, 0, 1, 4), []float64{80, 80.1, 79.8}},
+		{"half a day", measurements(map[float64]float64{0: 80, 0.5: 70}, 0, 0.5), []float64{80, 79.49}},
+		{"same moment", measurements(map[float64]float64{0: 80}, 0, 0), []float64{80, 80}},
+		{
+			"out of order",
+			[]models.Measurement{{Value: 80, MeasuredAt: day(1)}, {Value: 70, MeasuredAt: day(0)}},
+			[]float64{80, 80},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := Trend(tt.entries)
+			if len(points) != len(tt.want) {
+				t.Fatalf("Trend() returned %d points, want %d", len(points), len(tt.want))
+			}
+			for i, point := range points {
+				if point.Trend != tt.want[i] {
+					t.Errorf("trend of point %d = %v, want %v", i, point.Trend, tt.want[i])
+				}
+				if point.Value != tt.entries[i].Value || !point.MeasuredAt.Equal(tt.entries[i].MeasuredAt) {
+					t.Errorf("point %d = %v at %v, want the measurement %v at %v",
+						i, point.Value, point.MeasuredAt, tt.entries[i].Value, tt.entries[i].MeasuredAt)
+				}
+			}
+		})
+	}
+}
+
+func TestTrendAt(t *testing.T) {
+	points := []TrendPoint{
+		{MeasuredAt: day(0), Trend: 80},
+		{MeasuredAt: day(3), Trend: 79.5},
+		{MeasuredAt: day(10), Trend: 79},
+	}
+	tests := []struct {
+		name   string
+		at     time.Time
+		want   float64
+		wantOK bool
+	}{
+		{"before the first point", day(-1), 0, false},
+		{"at a point", day(3), 79.5, true},
+		{"between points", day(9.5), 79.5, true},
+		{"after the last point", day(40), 79, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := TrendAt(points, tt.at)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("TrendAt(%v) = %v, %v, want %v, %v", tt.at, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if _, ok := TrendAt(nil, day(0)); ok {
+		t.Error("TrendAt() without points found a trend")
+	}
+}
+
+func TestWeeklyRate(t *testing.T) {
+	// trends returns trend points on the given days after the start
+	trends := func(values map[float64]float64, days ...float64) []TrendPoint {
+		points := make([]TrendPoint, len(days))
+		for i, d := range days {
+			points[i] = TrendPoint{MeasuredAt: day(d), Trend: values[d]}
+		}
+		return points
+	}
+	rate := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name   string
+		points []TrendPoint
+		want   *float64
+	}{
+		{"no points", nil, nil},
+		{"one point", trends(map[float64]float64{0: 80}, 0), nil},
+		{"less than a day", trends(map[float64]float64{0: 80, 0.5: 79}, 0, 0.5), nil},
+		{"one week", trends(map[float64]float64{0: 80, 7: 79}, 0, 7), rate(-1)},
+		{"gaining", trends(map[float64]float64{0: 60, 14: 61}, 0, 14), rate(0.5)},
+		{"irregular gaps", trends(map[float64]float64{0: 80, 3: 79.5, 10: 79}, 0, 3, 10), rate(-0.7)},
+		{"start of the window", trends(map[float64]float64{0: 84, 28: 80}, 0, 28), rate(-1)},
+		// Only the last 28 days count, from the first point within them
+		{"older points", trends(map[float64]float64{0: 90, 20: 82, 40: 80}, 0, 20, 40), rate(-0.7)},
+		{"no point in the window but the last", trends(map[float64]float64{0: 90, 40: 80}, 0, 40), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WeeklyRate(tt.points)
+			switch {
+			case got == nil && tt.want == nil:
+			case got == nil || tt.want == nil:
+				t.Errorf("WeeklyRate() = %v, want %v", got, tt.want)
+			case *got != *tt.want:
+				t.Errorf("WeeklyRate() = %v, want %v", *got, *tt.want)
+			}
+		})
+	}
+}
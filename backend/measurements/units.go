@@ -0,0 +1,41 @@
+// Package measurements converts body measurements to the units they are
+// stored in and smooths them into trends.
+package measurements
+
+import (
+	"BAZ/Nutritracker/models"
+	"fmt"
+	"math"
+)
+
+// conversions give the factor that turns a unit into the stored unit.
+var conversions = map[string]map[string]float64{
+	"kg": {"kg": 1, "g": 0.001, "lb": 0.45359237, "st": 6.35029318},
+	"cm": {"cm": 1, "mm": 0.1, "m": 100, "in": 2.54},
+	"%":  {"%": 1},
+}
+
+// Normalize converts a value of a measurement kind to the stored unit. An
+// empty unit means the value already is in the stored unit. Percentages can't
+// be above 100.
+func Normalize(kind string, value float64, unit string) (float64, string, error) {
+	stored, ok := models.MeasurementUnits[kind]
+	if !ok {
+		return 0, "", fmt.Errorf("unknown measurement type %q", kind)
+	}
+	if unit == "" {
+		unit = stored
+	}
+	factor, ok := conversions[stored][unit]
+	if !ok {
+		return 0, "", fmt.Errorf("unit %q can't be used for %s", unit, kind)
+	}
+	if value <= 0 {
+		return 0, "", fmt.Errorf("%s must be positive", kind)
+	}
+	normalized := math.Round(value*factor*100) / 100
+	if stored == "%" && normalized > 100 {
+		return 0, "", fmt.Errorf("%s can't be above 100%%", kind)
+	}
+	return normalized, stored, nil
+}
@@ -0,0 +1,50 @@
+package measurements
+
+import (
+	"BAZ/Nutritracker/models"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		value   float64
+		unit    string
+		want    float64
+		wantErr bool
+	}{
+		{"stored unit", models.MeasurementWeight, 80, "kg", 80, false},
+		{"no unit", models.MeasurementWeight, 80, "", 80, false},
+		{"grams", models.MeasurementWeight, 80500, "g", 80.5, false},
+		{"pounds", models.MeasurementWeight, 176, "lb", 79.83, false},
+		{"stones", models.MeasurementWeight, 12, "st", 76.2, false},
+		{"inches", models.MeasurementWaist, 32, "in", 81.28, false},
+		{"meters", models.MeasurementHip, 0.95, "m", 95, false},
+		{"millimeters", models.MeasurementArm, 305, "mm", 30.5, false},
+		{"body fat", models.MeasurementBodyFat, 24.5, "%", 24.5, false},
+		{"all body fat", models.MeasurementBodyFat, 100, "", 100, false},
+		{"body fat above 100%", models.MeasurementBodyFat, 100.5, "%", 0, true},
+		{"unknown kind", "neck", 40, "cm", 0, true},
+		{"unit of another kind", models.MeasurementWaist, 80, "kg", 0, true},
+		{"unknown unit", models.MeasurementWeight, 80, "oz", 0, true},
+		{"zero", models.MeasurementWeight, 0, "kg", 0, true},
+		{"negative", models.MeasurementChest, -90, "cm", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unit, err := Normalize(tt.kind, tt.value, tt.unit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Normalize(%q, %v, %q) error = %v, want error %v", tt.kind, tt.value, tt.unit, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want || unit != models.MeasurementUnits[tt.kind] {
+				t.Errorf("Normalize(%q, %v, %q) = %v %s, want %v %s",
+					tt.kind, tt.value, tt.unit, got, unit, tt.want, models.MeasurementUnits[tt.kind])
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"BAZ/Nutritracker/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole only lets the request through if the user set by RequireAuth
+// has one of the given roles. It must be registered after RequireAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+		authenticatedUser := user.(models.User)
+
+		for _, role := range roles {
+			if authenticatedUser.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Insufficient permissions",
+		})
+		c.Abort()
+	}
+}
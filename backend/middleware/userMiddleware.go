@@ -1,16 +1,14 @@
 package middleware
 
 import (
+	"BAZ/Nutritracker/helpers"
 	"BAZ/Nutritracker/initializers"
 	"BAZ/Nutritracker/models"
-	"fmt"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 func RequireAuth(c *gin.Context) {
@@ -43,14 +41,7 @@ func RequireAuth(c *gin.Context) {
 	}
 
 	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-
+	claims, err := helpers.ParseAccessToken(tokenString)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid token",
@@ -59,34 +50,56 @@ func RequireAuth(c *gin.Context) {
 		return
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		// Check if token is expired
-		if float64(time.Now().Unix()) > claims["exp"].(float64) {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token expired",
-			})
-			c.Abort()
-			return
-		}
-
-		// Find user
-		var user models.User
-		if err := initializers.DB.First(&user, claims["sub"]).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "User not found",
-			})
-			c.Abort()
-			return
-		}
+	// Check if token is expired
+	exp, ok := claims["exp"].(float64)
+	if !ok || float64(time.Now().Unix()) > exp {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Token expired",
+		})
+		c.Abort()
+		return
+	}
 
-		// Attach user to context
-		c.Set("user", user)
-		c.Next()
-	} else {
+	// Tokens issued before sessions existed carry no "sid" and can't be revoked
+	sessionID, ok := claims["sid"].(float64)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid token claims",
 		})
 		c.Abort()
 		return
 	}
+
+	if initializers.DB == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "database connection not available",
+		})
+		c.Abort()
+		return
+	}
+
+	// Reject tokens whose session was logged out or has expired
+	var session models.UserSession
+	if err := initializers.DB.First(&session, uint(sessionID)).Error; err != nil || !session.IsActive(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Session revoked",
+		})
+		c.Abort()
+		return
+	}
+
+	// Find user
+	var user models.User
+	if err := initializers.DB.First(&user, claims["sub"]).Error; err != nil || user.ID != session.UserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found",
+		})
+		c.Abort()
+		return
+	}
+
+	// Attach user and session to context
+	c.Set("user", user)
+	c.Set("session", session)
+	c.Next()
 }
@@ -9,7 +9,8 @@ import (
 type Accomplished_achievements struct {
 	gorm.Model
 
-	Date_achieved time.Time `gorm:"type:datetime" json:"date_achieved"`
-	UserID        int       `gorm:"type:int" json:"user_id"`
-	AchievementID int       `gorm:"type:int" json:"achievement_id"`
+	Date_achieved time.Time   `gorm:"type:datetime" json:"date_achieved"`
+	UserID        uint        `gorm:"type:int;uniqueIndex:idx_user_achievement" json:"user_id"`
+	AchievementID uint        `gorm:"type:int;uniqueIndex:idx_user_achievement" json:"achievement_id"`
+	Achievement   Achievement `gorm:"foreignKey:AchievementID" json:"achievement"`
 }
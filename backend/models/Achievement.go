@@ -4,9 +4,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// Achievement is an entry of the achievement catalog. The catalog itself is
+// declared in the achievements package and synced to this table on startup.
 type Achievement struct {
 	gorm.Model
 
+	Key         string `gorm:"type:varchar(50);uniqueIndex" json:"key"`
 	Name        string `gorm:"type:text" json:"name"`
 	Description string `gorm:"type:text" json:"description"`
+	Rule        string `gorm:"type:varchar(50)" json:"rule"`
+	Threshold   int    `gorm:"type:int" json:"threshold"`
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	SexMale   = "male"
+	SexFemale = "female"
+)
+
+// Activity levels, from a desk job without exercise to hard daily training.
+const (
+	ActivitySedentary  = "sedentary"
+	ActivityLight      = "light"
+	ActivityModerate   = "moderate"
+	ActivityActive     = "active"
+	ActivityVeryActive = "very_active"
+)
+
+// BodyMetrics holds the body measurements of a user that energy needs are
+// computed from.
+type BodyMetrics struct {
+	gorm.Model
+	UserID        uint    `gorm:"type:int;uniqueIndex" json:"user_id"`
+	Sex           string  `gorm:"type:varchar(10)" json:"sex"` // male or female, as the formulas distinguish them
+	BirthDate     Date    `gorm:"type:date" json:"birth_date"`
+	HeightCm      float64 `gorm:"type:decimal(5,1)" json:"height_cm"`
+	WeightKg      float64 `gorm:"type:decimal(5,1)" json:"weight_kg"`
+	ActivityLevel string  `gorm:"type:varchar(20)" json:"activity_level"`
+}
+
+// IsValidSex reports whether sex is one of the sexes the formulas know.
+func IsValidSex(sex string) bool {
+	return sex == SexMale || sex == SexFemale
+}
+
+// IsValidActivityLevel reports whether level is one of the known activity levels.
+func IsValidActivityLevel(level string) bool {
+	switch level {
+	case ActivitySedentary, ActivityLight, ActivityModerate, ActivityActive, ActivityVeryActive:
+		return true
+	}
+	return false
+}
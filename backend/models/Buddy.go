@@ -4,9 +4,41 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	BuddyStatusPending  = "pending"
+	BuddyStatusAccepted = "accepted"
+	BuddyStatusBlocked  = "blocked"
+)
+
+// Buddy is the relationship between two peers. There is at most one record per
+// pair of users, whichever of them sent the first request is the requester.
+// The pair columns hold both IDs in order so the database enforces that, even
+// when two users ask each other at the same time.
 type Buddy struct {
 	gorm.Model
-	PatiendID int  `gorm:"type:int" json:"patiend_id"`
-	BuddyID   int  `gorm:"type:int" json:"guardian_id"`
-	Buddy     User `gorm:"foreignKey:GuardianID" json:"guardian"`
+	RequesterID uint   `gorm:"type:int;not null;index" json:"requester_id"`
+	Requester   User   `gorm:"foreignKey:RequesterID" json:"-"`
+	AddresseeID uint   `gorm:"type:int;not null;index" json:"addressee_id"`
+	Addressee   User   `gorm:"foreignKey:AddresseeID" json:"-"`
+	Status      string `gorm:"type:varchar(20);default:pending" json:"status"`
+	BlockedByID *uint  `gorm:"type:int" json:"-"`
+	PairLowID   uint   `gorm:"type:int;not null;uniqueIndex:idx_buddies_pair,priority:1" json:"-"`
+	PairHighID  uint   `gorm:"type:int;not null;uniqueIndex:idx_buddies_pair,priority:2" json:"-"`
+}
+
+// BeforeSave fills the pair columns from the requester and addressee.
+func (b *Buddy) BeforeSave(tx *gorm.DB) error {
+	b.PairLowID, b.PairHighID = b.RequesterID, b.AddresseeID
+	if b.PairLowID > b.PairHighID {
+		b.PairLowID, b.PairHighID = b.PairHighID, b.PairLowID
+	}
+	return nil
+}
+
+// Other returns the ID of the user on the other side of the relationship.
+func (b Buddy) Other(userID uint) uint {
+	if b.RequesterID == userID {
+		return b.AddresseeID
+	}
+	return b.RequesterID
 }
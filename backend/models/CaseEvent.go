@@ -0,0 +1,26 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const (
+	CaseEventCreated       = "created"
+	CaseEventStatusChanged = "status_changed"
+	CaseEventAssigned      = "assigned"
+	CaseEventComment       = "comment"
+)
+
+// CaseEvent is one entry in the audit trail of a case. ActorID is nil for
+// changes made by the system, such as automatically opened cases.
+type CaseEvent struct {
+	gorm.Model
+	CaseID     uint   `gorm:"type:int;not null;index" json:"case_id"`
+	ActorID    *uint  `gorm:"type:int" json:"actor_id"`
+	Actor      *User  `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	Type       string `gorm:"type:varchar(30)" json:"type"`
+	FromStatus string `gorm:"type:varchar(20)" json:"from_status,omitempty"`
+	ToStatus   string `gorm:"type:varchar(20)" json:"to_status,omitempty"`
+	AssigneeID *uint  `gorm:"type:int" json:"assignee_id,omitempty"`
+	Comment    string `gorm:"type:text" json:"comment,omitempty"`
+}
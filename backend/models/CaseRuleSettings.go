@@ -0,0 +1,30 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// CaseRuleSettings holds the per-patient thresholds for automatic case creation.
+type CaseRuleSettings struct {
+	gorm.Model
+	UserID                uint `gorm:"type:int;uniqueIndex" json:"user_id"`
+	Enabled               bool `gorm:"type:boolean" json:"enabled"`
+	MissedMealDays        int  `gorm:"type:int" json:"missed_meal_days"`        // days in a row without breakfast, lunch or dinner
+	LowCaloriesPercentage int  `gorm:"type:int" json:"low_calories_percentage"` // share of the calorie goal below which a day counts as low
+	LowCaloriesDays       int  `gorm:"type:int" json:"low_calories_days"`       // low calorie days in a row before a case is opened
+	WeightLossPercentage  int  `gorm:"type:int" json:"weight_loss_percentage"`  // share of the weight trend lost that opens a case
+	WeightLossDays        int  `gorm:"type:int" json:"weight_loss_days"`        // days the weight loss is measured over
+}
+
+// DefaultCaseRuleSettings returns the thresholds used for patients that have no settings of their own.
+func DefaultCaseRuleSettings(userID uint) CaseRuleSettings {
+	return CaseRuleSettings{
+		UserID:                userID,
+		Enabled:               true,
+		MissedMealDays:        2,
+		LowCaloriesPercentage: 50,
+		LowCaloriesDays:       3,
+		WeightLossPercentage:  5,
+		WeightLossDays:        30,
+	}
+}
@@ -4,10 +4,53 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	CaseStatusOpen         = "open"
+	CaseStatusAcknowledged = "acknowledged"
+	CaseStatusInProgress   = "in_progress"
+	CaseStatusResolved     = "resolved"
+	CaseStatusDismissed    = "dismissed"
+)
+
+// ActiveCaseStatuses are the statuses of cases that still need work.
+var ActiveCaseStatuses = []string{CaseStatusOpen, CaseStatusAcknowledged, CaseStatusInProgress}
+
+// caseTransitions lists the statuses a case can move to from each status.
+// Resolved and dismissed cases are closed for good.
+var caseTransitions = map[string][]string{
+	CaseStatusOpen:         {CaseStatusAcknowledged, CaseStatusDismissed},
+	CaseStatusAcknowledged: {CaseStatusInProgress, CaseStatusDismissed},
+	CaseStatusInProgress:   {CaseStatusResolved, CaseStatusDismissed},
+}
+
+// CanTransitionCase reports whether a case may move from one status to another.
+func CanTransitionCase(from string, to string) bool {
+	for _, status := range caseTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidCaseStatus reports whether status is one of the known case statuses.
+func IsValidCaseStatus(status string) bool {
+	switch status {
+	case CaseStatusOpen, CaseStatusAcknowledged, CaseStatusInProgress, CaseStatusResolved, CaseStatusDismissed:
+		return true
+	}
+	return false
+}
+
 type Cases struct {
 	gorm.Model
-	Status string `gorm:"type:text" json:"status"`
-	Reason string `gorm:"type:text" json:"reason"`
-	Note   string `gorm:"type:text" json:"notes"`
-	UserID int    `gorm:"type:int" json:"user_id"`
+	Status     string      `gorm:"type:varchar(20);default:open;index" json:"status"`
+	Reason     string      `gorm:"type:text" json:"reason"`
+	Note       string      `gorm:"type:text" json:"notes"`
+	Rule       string      `gorm:"type:varchar(50)" json:"rule"` // rule that opened the case, empty for manual cases
+	UserID     uint        `gorm:"type:int;index" json:"user_id"`
+	User       User        `gorm:"foreignKey:UserID" json:"user"`
+	AssigneeID *uint       `gorm:"type:int;index" json:"assignee_id"`
+	Assignee   *User       `gorm:"foreignKey:AssigneeID" json:"assignee,omitempty"`
+	Events     []CaseEvent `gorm:"foreignKey:CaseID" json:"events,omitempty"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// DailySummary holds the totals of the nutrilogs of a user on one day and the
+// goal that applied when they were last computed. The stats package keeps it
+// up to date in the same transaction as the nutrilog changes, so streaks and
+// reports don't have to sum raw nutrilogs.
+type DailySummary struct {
+	gorm.Model
+	UserID        uint            `gorm:"type:int;not null;uniqueIndex:idx_daily_summaries_user_date,priority:1" json:"user_id"`
+	Date          Date            `gorm:"type:date;not null;uniqueIndex:idx_daily_summaries_user_date,priority:2" json:"date"`
+	MealCount     int             `gorm:"type:int" json:"meal_count"`
+	Calories      float64         `gorm:"type:decimal(10,2)" json:"calories"`
+	Proteins      float64         `gorm:"type:decimal(10,2)" json:"proteins"`
+	Fats          float64         `gorm:"type:decimal(10,2)" json:"fats"`
+	Carbohydrates float64         `gorm:"type:decimal(10,2)" json:"carbohydrates"`
+	Nutrients     NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrients"`
+
+	// Snapshot of the active goal, empty when the user had none
+	NutritionGoalID *uint           `gorm:"type:int" json:"nutrition_goal_id"`
+	CaloriesGoal    int             `gorm:"type:int" json:"calories_goal"`
+	ProteinsGoal    int             `gorm:"type:int" json:"proteins_goal"`
+	FatsGoal        int             `gorm:"type:int" json:"fats_goal"`
+	CarbsGoal       int             `gorm:"type:int" json:"carbs_goal"`
+	NutrientGoals   NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrient_goals"`
+	GoalStatuses    GoalStatuses    `gorm:"serializer:json;type:json" json:"goal_statuses"` // under, within or over per goal key
+	GoalAchieved    bool            `gorm:"type:boolean" json:"goal_achieved"`
+}
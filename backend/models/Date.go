@@ -0,0 +1,134 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	DateLayout      = "2006-01-02"
+	TimeOfDayLayout = "15:04"
+)
+
+// Date is a calendar day, stored in a DATE column and written as YYYY-MM-DD.
+// Days have no timezone; the user's zone decides which day an instant falls on.
+type Date string
+
+// DateOf returns the day of t in the location of t.
+func DateOf(t time.Time) Date {
+	return Date(t.Format(DateLayout))
+}
+
+// ParseDate validates a YYYY-MM-DD day.
+func ParseDate(value string) (Date, error) {
+	day, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return DateOf(day), nil
+}
+
+// Time returns the start of the day in loc.
+func (d Date) Time(loc *time.Location) time.Time {
+	day, _ := time.ParseInLocation(DateLayout, string(d), loc)
+	return day
+}
+
+// AddDays returns the day n days later, or earlier for negative n.
+func (d Date) AddDays(n int) Date {
+	return DateOf(d.Time(time.UTC).AddDate(0, 0, n))
+}
+
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = ""
+	case time.Time:
+		*d = DateOf(v)
+	case []byte:
+		*d = Date(v)
+	case string:
+		*d = Date(v)
+	default:
+		return fmt.Errorf("can't scan %T into a Date", value)
+	}
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d == "" {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+// TimeOfDay is a wall clock time, stored in a TIME column and written as HH:MM.
+type TimeOfDay string
+
+// timeOfDayLayouts are the accepted time formats. The app sends the device's
+// locale time, which may use a 12-hour clock.
+var timeOfDayLayouts = []string{TimeOfDayLayout, "15:04:05", "3:04 PM", "3:04:05 PM", "3:04PM"}
+
+// ParseTimeOfDay validates a HH:MM time. Seconds are accepted and dropped.
+func ParseTimeOfDay(value string) (TimeOfDay, error) {
+	for _, layout := range timeOfDayLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return TimeOfDay(t.Format(TimeOfDayLayout)), nil
+		}
+	}
+	return "", fmt.Errorf("invalid time %q, expected HH:MM", value)
+}
+
+// TimeOfDayOf returns the wall clock time of t in the location of t.
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	return TimeOfDay(t.Format(TimeOfDayLayout))
+}
+
+func (t *TimeOfDay) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		*t = ""
+		return nil
+	case time.Time:
+		*t = TimeOfDayOf(v)
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("can't scan %T into a TimeOfDay", value)
+	}
+
+	// TIME columns read as HH:MM:SS
+	if len(text) > len(TimeOfDayLayout) {
+		text = text[:len(TimeOfDayLayout)]
+	}
+	*t = TimeOfDay(text)
+	return nil
+}
+
+func (t TimeOfDay) Value() (driver.Value, error) {
+	if t == "" {
+		return nil, nil
+	}
+	return string(t), nil
+}
+
+var locations sync.Map
+
+// LoadLocation returns the IANA timezone name as a location, caching it.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	FoodUnitGram       = "g"
+	FoodUnitMilliliter = "ml"
+)
+
+const (
+	FoodSourceUser          = "user"
+	FoodSourceOpenFoodFacts = "openfoodfacts"
+	FoodSourceUSDA          = "usda"
+)
+
+// Food is an entry of the food catalog. Nutrients are stored per 100 g, or per
+// 100 ml for drinks, so logs can be computed for any quantity.
+type Food struct {
+	gorm.Model
+	Name                string          `gorm:"type:varchar(255);index" json:"name"`
+	Brand               string          `gorm:"type:varchar(255)" json:"brand"`
+	Barcode             *string         `gorm:"type:varchar(32);uniqueIndex" json:"barcode"`
+	Unit                string          `gorm:"type:varchar(5);default:g" json:"unit"` // g or ml
+	CaloriesPer100      float64         `gorm:"type:decimal(10,2)" json:"calories_per_100"`
+	ProteinsPer100      float64         `gorm:"type:decimal(10,2)" json:"proteins_per_100"`
+	FatsPer100          float64         `gorm:"type:decimal(10,2)" json:"fats_per_100"`
+	CarbohydratesPer100 float64         `gorm:"type:decimal(10,2)" json:"carbohydrates_per_100"`
+	NutrientsPer100     NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrients_per_100"` // other nutrients, by key
+	Servings            []FoodServing   `gorm:"foreignKey:FoodID" json:"servings"`
+	CreatedByID         *uint           `gorm:"type:int" json:"created_by_id"` // nil for imported foods
+	Source              string          `gorm:"type:varchar(20);default:user;uniqueIndex:idx_food_source" json:"source"`
+	SourceID            *string         `gorm:"type:varchar(64);uniqueIndex:idx_food_source" json:"source_id"` // ID of the food at its source, nil for user foods
+}
+
+// BarcodeLookupMiss remembers barcodes no provider knew, so unknown products
+// aren't looked up remotely on every scan.
+type BarcodeLookupMiss struct {
+	gorm.Model
+	Barcode   string    `gorm:"type:varchar(32);uniqueIndex" json:"barcode"`
+	CheckedAt time.Time `gorm:"type:datetime" json:"checked_at"`
+}
+
+// FoodServing is a named portion of a food, such as "1 slice" or "1 cup".
+type FoodServing struct {
+	gorm.Model
+	FoodID   uint    `gorm:"type:int;not null;index" json:"food_id"`
+	Name     string  `gorm:"type:varchar(100)" json:"name"`
+	Quantity float64 `gorm:"type:decimal(10,2)" json:"quantity"` // in the unit of the food
+}
+
+// FoodNutrients are the nutrients of a portion of food.
+type FoodNutrients struct {
+	Calories      float64         `json:"calories"`
+	Proteins      float64         `json:"proteins"`
+	Fats          float64         `json:"fats"`
+	Carbohydrates float64         `json:"carbohydrates"`
+	Nutrients     NutrientAmounts `json:"nutrients,omitempty"`
+}
+
+// NutrientsFor scales the per-100 values of the food to the given quantity.
+func (f Food) NutrientsFor(quantity float64) FoodNutrients {
+	factor := quantity / 100
+	return FoodNutrients{
+		Calories:      f.CaloriesPer100 * factor,
+		Proteins:      f.ProteinsPer100 * factor,
+		Fats:          f.FatsPer100 * factor,
+		Carbohydrates: f.CarbohydratesPer100 * factor,
+		Nutrients:     f.NutrientsPer100.Scaled(factor),
+	}
+}
+
+// Scaled multiplies the nutrients by factor.
+func (n FoodNutrients) Scaled(factor float64) FoodNutrients {
+	return FoodNutrients{
+		Calories:      n.Calories * factor,
+		Proteins:      n.Proteins * factor,
+		Fats:          n.Fats * factor,
+		Carbohydrates: n.Carbohydrates * factor,
+		Nutrients:     n.Nutrients.Scaled(factor),
+	}
+}
+
+// Rounded returns calories and macros rounded to two decimals, as stored on a Nutrilog.
+func (n FoodNutrients) Rounded() (calories float64, proteins float64, fats float64, carbohydrates float64) {
+	return RoundMacro(n.Calories), RoundMacro(n.Proteins), RoundMacro(n.Fats), RoundMacro(n.Carbohydrates)
+}
+
+// RoundMacro rounds calories or a macro to the two decimals they are stored with.
+func RoundMacro(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
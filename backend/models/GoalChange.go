@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GoalChangeApplied  = "applied"
+	GoalChangePending  = "pending"
+	GoalChangeRejected = "rejected"
+)
+
+// GoalAdjustment is the change of one goal, by goal key.
+type GoalAdjustment struct {
+	Key  string  `json:"key"`
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+// GoalChange records an automatic change of a nutrition goal and why it was
+// made. Changes that need approval stay pending until a guardian or a
+// clinician reviews them.
+type GoalChange struct {
+	gorm.Model
+	UserID          uint             `gorm:"type:int;not null;index" json:"user_id"`
+	NutritionGoalID uint             `gorm:"type:int;not null;index" json:"nutrition_goal_id"`
+	PolicyID        *uint            `gorm:"type:int" json:"policy_id"` // empty when the default policy applied
+	Status          string           `gorm:"type:varchar(20)" json:"status"`
+	Adjustments     []GoalAdjustment `gorm:"serializer:json;type:json" json:"adjustments"`
+	Reason          string           `gorm:"type:text" json:"reason"`
+	Streak          int              `gorm:"type:int" json:"streak"`
+	ReviewedByID    *uint            `gorm:"type:int" json:"reviewed_by_id"`
+	ReviewedAt      *time.Time       `gorm:"type:datetime" json:"reviewed_at"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Keys of the calorie and macro goals in ranges, progression steps and ceilings.
+// Other nutrient goals use their nutrient key.
+const (
+	GoalKeyCalories      = "calories"
+	GoalKeyProteins      = "proteins"
+	GoalKeyFats          = "fats"
+	GoalKeyCarbohydrates = "carbohydrates"
+)
+
+// GoalProgressionPolicy decides how the nutrition goals of a user change
+// automatically once they keep reaching them.
+type GoalProgressionPolicy struct {
+	gorm.Model
+	UserID          uint            `gorm:"type:int;uniqueIndex" json:"user_id"`
+	Enabled         bool            `gorm:"type:boolean" json:"enabled"`               // goals never change automatically when false
+	RequiredStreak  int             `gorm:"type:int" json:"required_streak"`           // days in a row the goal has to be reached before a step
+	Tolerance       float64         `gorm:"type:decimal(4,3)" json:"tolerance"`        // share of a goal that counts as reached, a single calorie or macro goal may be passed by as much
+	Steps           NutrientAmounts `gorm:"serializer:json;type:json" json:"steps"`    // change in percent per goal key, negative to lower a goal
+	Ceilings        NutrientAmounts `gorm:"serializer:json;type:json" json:"ceilings"` // highest value per goal key a step may reach
+	RequireApproval bool            `gorm:"type:boolean" json:"require_approval"`      // steps wait for a guardian to approve them, needs a guardian the goals are shared with
+}
+
+// DefaultGoalProgressionPolicy returns the policy used for users that have no
+// policy of their own: every goal goes up 5% after 7 days in a row.
+func DefaultGoalProgressionPolicy(userID uint) GoalProgressionPolicy {
+	return GoalProgressionPolicy{
+		UserID:         userID,
+		Enabled:        true,
+		RequiredStreak: 7,
+		Tolerance:      0.9,
+		Steps: NutrientAmounts{
+			GoalKeyCalories:      5,
+			GoalKeyProteins:      5,
+			GoalKeyFats:          5,
+			GoalKeyCarbohydrates: 5,
+		},
+	}
+}
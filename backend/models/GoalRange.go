@@ -0,0 +1,73 @@
+package models
+
+// How the amount of a nutrient compares to its goal range.
+const (
+	GoalStatusUnder  = "under"
+	GoalStatusWithin = "within"
+	GoalStatusOver   = "over"
+)
+
+// GoalRange bounds the daily amount of a nutrient, for example fats between
+// 50 and 80 g. Either bound may be left out. Tolerance is the share by which
+// the amount may pass a bound and still count as within, 0.1 for 10%. When it
+// is empty the tolerance of the user's progression policy applies.
+type GoalRange struct {
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	Tolerance *float64 `json:"tolerance,omitempty"`
+}
+
+// GoalRanges maps goal keys to their range.
+type GoalRanges map[string]GoalRange
+
+// Status compares amount to the range, allowing the given tolerance.
+func (r GoalRange) Status(amount float64, tolerance float64) string {
+	if r.Min != nil && amount < *r.Min*(1-tolerance) {
+		return GoalStatusUnder
+	}
+	if r.Max != nil && amount > *r.Max*(1+tolerance) {
+		return GoalStatusOver
+	}
+	return GoalStatusWithin
+}
+
+// Scaled multiplies both bounds by factor, keeping the tolerance.
+func (r GoalRange) Scaled(factor float64) GoalRange {
+	scaled := GoalRange{Tolerance: r.Tolerance}
+	if r.Min != nil {
+		min := *r.Min * factor
+		scaled.Min = &min
+	}
+	if r.Max != nil {
+		max := *r.Max * factor
+		scaled.Max = &max
+	}
+	return scaled
+}
+
+// GoalStatus is the amount of a nutrient on a day against its goal range.
+type GoalStatus struct {
+	Amount    float64  `json:"amount"`
+	Min       *float64 `json:"min"`
+	Max       *float64 `json:"max"`
+	Tolerance float64  `json:"tolerance"`
+	Status    string   `json:"status"`
+}
+
+// GoalStatuses maps goal keys to their status.
+type GoalStatuses map[string]GoalStatus
+
+// Target returns the goal for a key, 0 when there is none.
+func (g NutritionGoal) Target(key string) float64 {
+	switch key {
+	case GoalKeyCalories:
+		return float64(g.CaloriesGoal)
+	case GoalKeyProteins:
+		return float64(g.ProteinsGoal)
+	case GoalKeyFats:
+		return float64(g.FatsGoal)
+	case GoalKeyCarbohydrates:
+		return float64(g.CarbsGoal)
+	}
+	return g.NutrientGoals[key]
+}
@@ -1,12 +1,42 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// Guardian links a patient to a guardian who may follow their progress. A link
+// is created when the guardian redeems an invitation from the patient and stays
+// active until either side revokes it.
 type Guardian struct {
 	gorm.Model
-	PatiendID  int  `gorm:"type:int" json:"patiend_id"`
-	GuardianID int  `gorm:"type:int" json:"guardian_id"`
-	Guardian   User `gorm:"foreignKey:GuardianID" json:"guardian"`
+	PatientID    uint       `gorm:"type:int;not null;index" json:"patient_id"`
+	Patient      User       `gorm:"foreignKey:PatientID" json:"patient"`
+	GuardianID   uint       `gorm:"type:int;not null;index" json:"guardian_id"`
+	Guardian     User       `gorm:"foreignKey:GuardianID" json:"guardian"`
+	InvitationID uint       `gorm:"type:int" json:"invitation_id"`
+	ConsentedAt  time.Time  `gorm:"type:datetime" json:"consented_at"`
+	RevokedAt    *time.Time `gorm:"type:datetime" json:"revoked_at"`
+
+	// What the patient shares with this guardian. Daily totals are always shared.
+	ShareGoals            bool `gorm:"type:boolean" json:"share_goals"`
+	ShareMeals            bool `gorm:"type:boolean" json:"share_meals"`
+	ShareMealDescriptions bool `gorm:"type:boolean" json:"share_meal_descriptions"`
+	ShareMeasurements     bool `gorm:"type:boolean" json:"share_measurements"`
+}
+
+// GuardianInvitation is a time-limited code a patient hands to a guardian.
+type GuardianInvitation struct {
+	gorm.Model
+	Code         string     `gorm:"type:varchar(16);uniqueIndex" json:"code"`
+	PatientID    uint       `gorm:"type:int;not null;index" json:"patient_id"`
+	ExpiresAt    time.Time  `gorm:"type:datetime" json:"expires_at"`
+	RedeemedAt   *time.Time `gorm:"type:datetime" json:"redeemed_at"`
+	RedeemedByID *uint      `gorm:"type:int" json:"redeemed_by_id"`
+}
+
+// IsRedeemable reports whether the invitation can still be used at the given time.
+func (i GuardianInvitation) IsRedeemable(now time.Time) bool {
+	return i.RedeemedAt == nil && now.Before(i.ExpiresAt)
 }
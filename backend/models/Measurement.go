@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of body measurements.
+const (
+	MeasurementWeight  = "weight"
+	MeasurementWaist   = "waist"
+	MeasurementHip     = "hip"
+	MeasurementChest   = "chest"
+	MeasurementArm     = "arm"
+	MeasurementThigh   = "thigh"
+	MeasurementBodyFat = "body_fat"
+)
+
+// MeasurementUnits maps each kind of measurement to the unit it is stored in.
+// Other units are converted on the way in.
+var MeasurementUnits = map[string]string{
+	MeasurementWeight:  "kg",
+	MeasurementWaist:   "cm",
+	MeasurementHip:     "cm",
+	MeasurementChest:   "cm",
+	MeasurementArm:     "cm",
+	MeasurementThigh:   "cm",
+	MeasurementBodyFat: "%",
+}
+
+// Measurement is one body measurement of a user, such as their weight on a
+// morning.
+type Measurement struct {
+	gorm.Model
+	UserID     uint      `gorm:"type:int;not null;index:idx_measurements_user_type,priority:1" json:"user_id"`
+	Type       string    `gorm:"type:varchar(20);not null;index:idx_measurements_user_type,priority:2" json:"type"`
+	Value      float64   `gorm:"type:decimal(7,2)" json:"value"`
+	Unit       string    `gorm:"type:varchar(10)" json:"unit"`
+	MeasuredAt time.Time `gorm:"type:datetime;index" json:"measured_at"`
+	Note       string    `gorm:"type:text" json:"note"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// MessageTemplate is an entry in the motivational message catalog that admins
+// maintain. The seed_motivational_messages script copies the active templates
+// into every user's MotivationalMessage feed.
+type MessageTemplate struct {
+	gorm.Model
+	Message      string `gorm:"type:text" json:"message"`
+	MessageType  string `gorm:"type:text" json:"message_type"` // breakfast, lunch, dinner, general
+	ScheduledFor string `gorm:"type:text" json:"scheduled_for"`
+	IsActive     bool   `gorm:"type:boolean;default:true" json:"is_active"`
+}
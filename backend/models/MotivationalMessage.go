@@ -7,9 +7,10 @@ import (
 type MotivationalMessage struct {
 	gorm.Model
 	Message     string `gorm:"type:text" json:"message"`
-	MessageType string `gorm:"type:text" json:"message_type"` // breakfast, lunch, dinner, general
+	MessageType string `gorm:"type:text" json:"message_type"` // breakfast, lunch, dinner, general, buddy
 	UserID      uint   `gorm:"type:int" json:"user_id"`
 	User        User   `gorm:"foreignKey:UserID" json:"user"`
 	IsRead      bool   `gorm:"type:boolean;default:false" json:"is_read"`
 	ScheduledFor string `gorm:"type:text" json:"scheduled_for"` // Time of day to show this message
+	SenderID    *uint  `gorm:"type:int" json:"sender_id"`      // Buddy who sent the message, nil for system messages
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Notification is a message for a user about something that happened to
+// someone else, such as a case opened for a patient they are a guardian of or
+// a goal change waiting for their approval.
+type Notification struct {
+	gorm.Model
+	UserID       uint   `gorm:"type:int;not null;index" json:"user_id"`
+	Type         string `gorm:"type:varchar(50)" json:"type"` // case_opened, goal_change_pending
+	Message      string `gorm:"type:text" json:"message"`
+	CaseID       *uint  `gorm:"type:int" json:"case_id"`
+	GoalChangeID *uint  `gorm:"type:int" json:"goal_change_id"`
+	IsRead       bool   `gorm:"type:boolean;default:false" json:"is_read"`
+}
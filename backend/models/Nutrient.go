@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// Nutrient is a nutrient tracked beyond calories and the three macros, such
+// as fiber or sodium. Foods, nutrilogs and goals carry amounts of them by key.
+type Nutrient struct {
+	gorm.Model
+	Key        string `gorm:"type:varchar(50);uniqueIndex" json:"key"`
+	Name       string `gorm:"type:varchar(100)" json:"name"`
+	Unit       string `gorm:"type:varchar(10)" json:"unit"`    // g, mg, µg or ml
+	UpperLimit bool   `gorm:"type:boolean" json:"upper_limit"` // goals are limits not to exceed, as for sodium or sugar
+}
+
+// NutrientAmounts maps nutrient keys to amounts in the unit of the nutrient.
+// It is stored as a JSON column so nutrients can be added without migrations.
+type NutrientAmounts map[string]float64
+
+// nutrientDecimals is the precision amounts are stored with.
+const nutrientDecimals = 3
+
+// Add returns the sum of both amounts.
+func (a NutrientAmounts) Add(other NutrientAmounts) NutrientAmounts {
+	if len(a) == 0 && len(other) == 0 {
+		return nil
+	}
+	sum := make(NutrientAmounts, len(a)+len(other))
+	for key, amount := range a {
+		sum[key] += amount
+	}
+	for key, amount := range other {
+		sum[key] += amount
+	}
+	return sum
+}
+
+// Scaled multiplies all amounts by factor.
+func (a NutrientAmounts) Scaled(factor float64) NutrientAmounts {
+	if len(a) == 0 {
+		return nil
+	}
+	scaled := make(NutrientAmounts, len(a))
+	for key, amount := range a {
+		scaled[key] = amount * factor
+	}
+	return scaled
+}
+
+// Rounded rounds all amounts to the stored precision.
+func (a NutrientAmounts) Rounded() NutrientAmounts {
+	if len(a) == 0 {
+		return nil
+	}
+	scale := math.Pow(10, nutrientDecimals)
+	rounded := make(NutrientAmounts, len(a))
+	for key, amount := range a {
+		rounded[key] = math.Round(amount*scale) / scale
+	}
+	return rounded
+}
@@ -1,19 +1,49 @@
 package models
 
 import (
+	"strings"
+
 	"gorm.io/gorm"
 )
 
+const (
+	NutrilogSourceManual    = "manual"
+	NutrilogSourceBarcode   = "barcode"
+	NutrilogSourceRecipe    = "recipe"
+	NutrilogSourceSavedMeal = "saved_meal"
+	NutrilogSourceCopy      = "copy"
+)
+
+// MealTypes are the meals a nutrilog can belong to.
+var MealTypes = []string{"breakfast", "lunch", "dinner", "snack"}
+
+// ValidMealType reports whether mealType is one of MealTypes, in any case.
+func ValidMealType(mealType string) bool {
+	for _, known := range MealTypes {
+		if strings.EqualFold(mealType, known) {
+			return true
+		}
+	}
+	return false
+}
+
 type Nutrilog struct {
 	gorm.Model
-	Calories    int    `gorm:"type:int" json:"calories"`
-	Proteins    int    `gorm:"type:int" json:"proteins"`
-	Fats        int    `gorm:"type:int" json:"fats"`
-	Carbohydrates int  `gorm:"type:int" json:"carbohydrates"`
+	Calories    float64 `gorm:"type:decimal(10,2)" json:"calories"`
+	Proteins    float64 `gorm:"type:decimal(10,2)" json:"proteins"`
+	Fats        float64 `gorm:"type:decimal(10,2)" json:"fats"`
+	Carbohydrates float64 `gorm:"type:decimal(10,2)" json:"carbohydrates"`
+	Nutrients   NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrients"` // other nutrients, by key
 	MealType    string `gorm:"type:text" json:"meal_type"`
-	MealTime    string `gorm:"type:text" json:"meal_time"`
-	MealDate    string `gorm:"type:text" json:"meal_date"`
+	MealTime    TimeOfDay `gorm:"type:time" json:"meal_time"` // HH:MM in the user's timezone
+	MealDate    Date   `gorm:"type:date;index:idx_nutrilogs_user_date,priority:2" json:"meal_date"` // day in the user's timezone
 	MealDescription string `gorm:"type:text" json:"meal_description"`
-	UserID      uint   `gorm:"type:int" json:"user_id"`
+	Source      string `gorm:"type:varchar(20);default:manual" json:"source"` // how the meal was entered: manual, barcode, recipe, saved_meal, copy
+	FoodID      *uint   `gorm:"type:int;index" json:"food_id"` // set when logged from the food catalog
+	Food        *Food   `gorm:"foreignKey:FoodID" json:"food,omitempty"`
+	Quantity    float64 `gorm:"type:decimal(10,2)" json:"quantity"` // amount of the food in g or ml
+	RecipeID    *uint   `gorm:"type:int;index" json:"recipe_id"` // set when logged from a recipe; the nutrients are a snapshot
+	Servings    float64 `gorm:"type:decimal(10,2)" json:"servings"` // servings of the recipe
+	UserID      uint   `gorm:"type:int;index:idx_nutrilogs_user_date,priority:1" json:"user_id"`
 	User        User   `gorm:"foreignKey:UserID" json:"user"`
 }
@@ -5,6 +5,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// NutritionGoal is one version of the goals of a user. Versions are never
+// changed once replaced: a new goal, an edit or a progression step closes the
+// active version and starts a new one, so the goal in effect on any past day
+// can be looked up. Only the progress counters change on the active version.
 type NutritionGoal struct {
 	gorm.Model
 	UserID        uint      `gorm:"type:int;not null" json:"user_id"`
@@ -13,8 +17,14 @@ type NutritionGoal struct {
 	ProteinsGoal  int       `gorm:"type:int;default:75" json:"proteins_goal"`
 	FatsGoal      int       `gorm:"type:int;default:65" json:"fats_goal"`
 	CarbsGoal     int       `gorm:"type:int;default:250" json:"carbs_goal"`
+	NutrientGoals NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrient_goals"` // goals for other nutrients, by key
+	Ranges        GoalRanges `gorm:"serializer:json;type:json" json:"ranges"` // lower and upper bounds by goal key, override the single goals
 	IsActive      bool      `gorm:"type:boolean;default:true" json:"is_active"`
 	StartDate     time.Time `gorm:"type:datetime" json:"start_date"`
 	GoalAchievedDays int    `gorm:"type:int;default:0" json:"goal_achieved_days"`
 	LastAchievedDate *time.Time `gorm:"type:datetime" json:"last_achieved_date"`
+	EffectiveFrom Date      `gorm:"type:date;index" json:"effective_from"` // first day the version applied
+	EffectiveTo   *Date     `gorm:"type:date" json:"effective_to"`         // last day it applied, empty while active
+	PreviousID    *uint     `gorm:"type:int" json:"previous_id"`           // version this one replaced
+	GoalChangeID  *uint     `gorm:"type:int" json:"goal_change_id"`        // automatic change that made this version
 }
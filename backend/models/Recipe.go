@@ -0,0 +1,52 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// Recipe is a dish a user cooks repeatedly, made of ingredient lines and
+// yielding a number of servings.
+type Recipe struct {
+	gorm.Model
+	UserID      uint               `gorm:"type:int;not null;index" json:"user_id"`
+	Name        string             `gorm:"type:varchar(255)" json:"name"`
+	Description string             `gorm:"type:text" json:"description"`
+	Servings    float64            `gorm:"type:decimal(10,2)" json:"servings"` // how many servings the recipe yields
+	Ingredients []RecipeIngredient `gorm:"foreignKey:RecipeID" json:"ingredients"`
+	Total       FoodNutrients      `gorm:"-" json:"total"`
+	PerServing  FoodNutrients      `gorm:"-" json:"per_serving"`
+}
+
+// RecipeIngredient is a line of a recipe: a quantity of a catalog food, or a
+// free ingredient with nutrients entered by hand. Nutrients of food lines are
+// computed when the line is saved, so later catalog edits don't change the recipe.
+type RecipeIngredient struct {
+	gorm.Model
+	RecipeID      uint            `gorm:"type:int;not null;index" json:"recipe_id"`
+	FoodID        *uint           `gorm:"type:int" json:"food_id"` // nil for free ingredients
+	Name          string          `gorm:"type:varchar(255)" json:"name"`
+	Quantity      float64         `gorm:"type:decimal(10,2)" json:"quantity"` // in the unit of the food
+	Calories      float64         `gorm:"type:decimal(10,2)" json:"calories"`
+	Proteins      float64         `gorm:"type:decimal(10,2)" json:"proteins"`
+	Fats          float64         `gorm:"type:decimal(10,2)" json:"fats"`
+	Carbohydrates float64         `gorm:"type:decimal(10,2)" json:"carbohydrates"`
+	Nutrients     NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrients"`
+}
+
+// CalculateNutrition sums the ingredient lines into Total and PerServing.
+// Ingredients must be loaded.
+func (r *Recipe) CalculateNutrition() {
+	r.Total = FoodNutrients{}
+	for _, ingredient := range r.Ingredients {
+		r.Total.Calories += ingredient.Calories
+		r.Total.Proteins += ingredient.Proteins
+		r.Total.Fats += ingredient.Fats
+		r.Total.Carbohydrates += ingredient.Carbohydrates
+		r.Total.Nutrients = r.Total.Nutrients.Add(ingredient.Nutrients)
+	}
+
+	r.PerServing = FoodNutrients{}
+	if r.Servings > 0 {
+		r.PerServing = r.Total.Scaled(1 / r.Servings)
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// SavedMeal is a favorite meal: a named bundle of items captured from
+// nutrilogs that can be logged again in one go.
+type SavedMeal struct {
+	gorm.Model
+	UserID   uint            `gorm:"type:int;not null;index" json:"user_id"`
+	Name     string          `gorm:"type:varchar(255)" json:"name"`
+	MealType string          `gorm:"type:varchar(20)" json:"meal_type"` // meal type of the captured nutrilogs
+	Items    []SavedMealItem `gorm:"foreignKey:SavedMealID" json:"items"`
+}
+
+// SavedMealItem is a copy of a nutrilog, without its date and user.
+type SavedMealItem struct {
+	gorm.Model
+	SavedMealID     uint            `gorm:"type:int;not null;index" json:"saved_meal_id"`
+	Calories        float64         `gorm:"type:decimal(10,2)" json:"calories"`
+	Proteins        float64         `gorm:"type:decimal(10,2)" json:"proteins"`
+	Fats            float64         `gorm:"type:decimal(10,2)" json:"fats"`
+	Carbohydrates   float64         `gorm:"type:decimal(10,2)" json:"carbohydrates"`
+	Nutrients       NutrientAmounts `gorm:"serializer:json;type:json" json:"nutrients"`
+	MealTime        TimeOfDay       `gorm:"type:time" json:"meal_time"`
+	MealDescription string          `gorm:"type:text" json:"meal_description"`
+	FoodID          *uint           `gorm:"type:int" json:"food_id"`
+	Quantity        float64         `gorm:"type:decimal(10,2)" json:"quantity"`
+	RecipeID        *uint           `gorm:"type:int" json:"recipe_id"`
+	Servings        float64         `gorm:"type:decimal(10,2)" json:"servings"`
+}
+
+// NewSavedMealItem captures a nutrilog.
+func NewSavedMealItem(nutrilog Nutrilog) SavedMealItem {
+	return SavedMealItem{
+		Calories:        nutrilog.Calories,
+		Proteins:        nutrilog.Proteins,
+		Fats:            nutrilog.Fats,
+		Carbohydrates:   nutrilog.Carbohydrates,
+		Nutrients:       nutrilog.Nutrients,
+		MealTime:        nutrilog.MealTime,
+		MealDescription: nutrilog.MealDescription,
+		FoodID:          nutrilog.FoodID,
+		Quantity:        nutrilog.Quantity,
+		RecipeID:        nutrilog.RecipeID,
+		Servings:        nutrilog.Servings,
+	}
+}
+
+// Nutrilog turns the item back into a nutrilog, without user, date and meal type.
+func (item SavedMealItem) Nutrilog() Nutrilog {
+	return Nutrilog{
+		Calories:        item.Calories,
+		Proteins:        item.Proteins,
+		Fats:            item.Fats,
+		Carbohydrates:   item.Carbohydrates,
+		Nutrients:       item.Nutrients,
+		MealTime:        item.MealTime,
+		MealDescription: item.MealDescription,
+		FoodID:          item.FoodID,
+		Quantity:        item.Quantity,
+		RecipeID:        item.RecipeID,
+		Servings:        item.Servings,
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"gorm.io/gorm"
 )
 
+// Stats is a per-user summary kept up to date by the stats package whenever
+// nutrilogs, cases or achievements change.
 type Stats struct {
 	gorm.Model
 
-	Streak              int `gorm:"type:int" json:"streak"`
-	Cases               int `gorm:"type:int" json:"cases"`
-	Achievements_gained int `gorm:"type:int" json:"achievements_gained"`
-	UserID              int `gorm:"type:int" json:"user_id"`
+	Streak              int  `gorm:"type:int" json:"streak"`         // days in a row with a logged meal, ending on LastLogDate
+	LastLogDate         Date `gorm:"type:date" json:"last_log_date"` // day in the user's timezone
+	Cases               int  `gorm:"type:int" json:"cases"`          // cases that are not resolved or dismissed
+	Achievements_gained int  `gorm:"type:int" json:"achievements_gained"`
+	UserID              uint `gorm:"type:int;uniqueIndex" json:"user_id"`
 }
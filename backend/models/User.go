@@ -1,9 +1,18 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
+const (
+	RolePatient   = "patient"
+	RoleGuardian  = "guardian"
+	RoleClinician = "clinician"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	gorm.Model
 	Email       string `gorm:"type:text" json:"email"`
@@ -12,4 +21,32 @@ type User struct {
 	FirstName   string `gorm:"type:text" json:"first_name"`
 	LastName    string `gorm:"type:text" json:"last_name"`
 	PhoneNumber string `gorm:"type:text" json:"phone_number"`
+	Role        string `gorm:"type:varchar(20);default:patient" json:"role"`
+	Timezone    string `gorm:"type:varchar(64)" json:"timezone"` // IANA name such as Europe/Paris
+}
+
+// Location returns the timezone of the user, or the server's when it isn't set.
+func (u User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.Local
+	}
+	loc, err := LoadLocation(u.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Today returns the current day in the user's timezone.
+func (u User) Today(now time.Time) Date {
+	return DateOf(now.In(u.Location()))
+}
+
+// IsValidRole reports whether role is one of the known user roles.
+func IsValidRole(role string) bool {
+	switch role {
+	case RolePatient, RoleGuardian, RoleClinician, RoleAdmin:
+		return true
+	}
+	return false
 }
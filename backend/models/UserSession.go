@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserSession backs a refresh token. Access tokens carry the session ID in
+// their "sid" claim so revoking the session also invalidates them.
+type UserSession struct {
+	gorm.Model
+	UserID           uint       `gorm:"type:int;not null;index" json:"user_id"`
+	User             User       `gorm:"foreignKey:UserID" json:"-"`
+	RefreshTokenHash string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	UserAgent        string     `gorm:"type:text" json:"user_agent"`
+	ExpiresAt        time.Time  `gorm:"type:datetime" json:"expires_at"`
+	LastUsedAt       *time.Time `gorm:"type:datetime" json:"last_used_at"`
+	RevokedAt        *time.Time `gorm:"type:datetime" json:"revoked_at"`
+}
+
+// IsActive reports whether the session can still be used at the given time.
+func (s UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
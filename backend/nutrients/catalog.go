@@ -0,0 +1,80 @@
+package nutrients
+
+import (
+	"BAZ/Nutritracker/models"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Keys of the nutrients in the catalog.
+const (
+	Fiber        = "fiber"
+	Sugar        = "sugar"
+	SaturatedFat = "saturated_fat"
+	Sodium       = "sodium"
+	Cholesterol  = "cholesterol"
+	Water        = "water"
+	Potassium    = "potassium"
+	Calcium      = "calcium"
+	Iron         = "iron"
+	VitaminA     = "vitamin_a"
+	VitaminC     = "vitamin_c"
+	VitaminD     = "vitamin_d"
+)
+
+// Catalog is the list of nutrients that can be tracked besides calories and
+// macros. Add new nutrients here, they are synced to the database by SyncCatalog.
+var Catalog = []models.Nutrient{
+	{Key: Fiber, Name: "Fiber", Unit: "g"},
+	{Key: Sugar, Name: "Sugar", Unit: "g", UpperLimit: true},
+	{Key: SaturatedFat, Name: "Saturated fat", Unit: "g", UpperLimit: true},
+	{Key: Sodium, Name: "Sodium", Unit: "mg", UpperLimit: true},
+	{Key: Cholesterol, Name: "Cholesterol", Unit: "mg", UpperLimit: true},
+	{Key: Water, Name: "Water", Unit: "ml"},
+	{Key: Potassium, Name: "Potassium", Unit: "mg"},
+	{Key: Calcium, Name: "Calcium", Unit: "mg"},
+	{Key: Iron, Name: "Iron", Unit: "mg"},
+	{Key: VitaminA, Name: "Vitamin A", Unit: "µg"},
+	{Key: VitaminC, Name: "Vitamin C", Unit: "mg"},
+	{Key: VitaminD, Name: "Vitamin D", Unit: "µg"},
+}
+
+// SyncCatalog inserts new catalog entries and updates changed ones.
+func SyncCatalog(db *gorm.DB) error {
+	for _, nutrient := range Catalog {
+		entry := nutrient
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "unit", "upper_limit", "updated_at"}),
+		}).Create(&entry).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find returns the catalog entry of a nutrient.
+func Find(key string) (models.Nutrient, bool) {
+	for _, nutrient := range Catalog {
+		if nutrient.Key == key {
+			return nutrient, true
+		}
+	}
+	return models.Nutrient{}, false
+}
+
+// Validate checks that amounts only use known nutrients and aren't negative.
+func Validate(amounts models.NutrientAmounts) error {
+	for key, amount := range amounts {
+		if _, ok := Find(key); !ok {
+			return fmt.Errorf("unknown nutrient %q", key)
+		}
+		if amount < 0 {
+			return fmt.Errorf("amount of %s can't be negative", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package progression
+
+import (
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Decision is the outcome of checking a goal against a progression policy.
+// It has no adjustments when the goal stays as it is.
+type Decision struct {
+	Adjustments   []models.GoalAdjustment
+	NeedsApproval bool
+	Reason        string
+}
+
+// Changes reports whether the policy asks for a change of the goal.
+func (d Decision) Changes() bool {
+	return len(d.Adjustments) > 0
+}
+
+// Validate checks that a policy only uses known goal keys and sane values.
+func Validate(policy models.GoalProgressionPolicy) error {
+	if policy.RequiredStreak < 1 {
+		return errors.New("required_streak must be at least 1")
+	}
+	if policy.Tolerance <= 0 || policy.Tolerance > 1 {
+		return errors.New("tolerance must be between 0 and 1")
+	}
+	for key, step := range policy.Steps {
+		if !knownKey(key) {
+			return fmt.Errorf("unknown goal %q", key)
+		}
+		if step <= -100 {
+			return fmt.Errorf("step of %s must be above -100%%", key)
+		}
+	}
+	for key, ceiling := range policy.Ceilings {
+		if !knownKey(key) {
+			return fmt.Errorf("unknown goal %q", key)
+		}
+		if ceiling < 0 {
+			return fmt.Errorf("ceiling of %s can't be negative", key)
+		}
+	}
+	return nil
+}
+
+// Evaluate decides whether a goal reached streak days in a row should change
+// under the policy, and by how much.
+func Evaluate(policy models.GoalProgressionPolicy, goal models.NutritionGoal, streak int) Decision {
+	if !policy.Enabled {
+		return Decision{Reason: "automatic progression is disabled"}
+	}
+	if streak < policy.RequiredStreak {
+		return Decision{Reason: fmt.Sprintf("goal reached %d of %d days in a row", streak, policy.RequiredStreak)}
+	}
+
+	var adjustments []models.GoalAdjustment
+	var details []string
+	for _, key := range sortedKeys(policy.Steps) {
+		step := policy.Steps[key]
+		current := goal.Target(key)
+		if step == 0 || current == 0 {
+			continue
+		}
+
+		target := current * (1 + step/100)
+		detail := fmt.Sprintf("%s %+g%%", key, step)
+		if ceiling, ok := policy.Ceilings[key]; ok && step > 0 && target > ceiling {
+			target = ceiling
+			detail += fmt.Sprintf(" capped at %g", ceiling)
+		}
+		target = roundGoal(key, target)
+		if target == current || (step > 0 && target < current) {
+			continue
+		}
+
+		adjustments = append(adjustments, models.GoalAdjustment{Key: key, From: current, To: target})
+		details = append(details, fmt.Sprintf("%s (%g to %g)", detail, current, target))
+	}
+
+	if len(adjustments) == 0 {
+		return Decision{Reason: fmt.Sprintf("goal reached %d days in a row but every goal is at its ceiling", streak)}
+	}
+	return Decision{
+		Adjustments:   adjustments,
+		NeedsApproval: policy.RequireApproval,
+		Reason: fmt.Sprintf("goal reached %d days in a row (policy requires %d): %s",
+			streak, policy.RequiredStreak, strings.Join(details, ", ")),
+	}
+}
+
+// Apply sets the goal to the new values of the adjustments. A range of an
+// adjusted goal moves by the same share.
+func Apply(goal *models.NutritionGoal, adjustments []models.GoalAdjustment) {
+	for _, adjustment := range adjustments {
+		if goalRange, ok := goal.Ranges[adjustment.Key]; ok && adjustment.From != 0 {
+			ranges := make(models.GoalRanges, len(goal.Ranges))
+			for key, value := range goal.Ranges {
+				ranges[key] = value
+			}
+			ranges[adjustment.Key] = goalRange.Scaled(adjustment.To / adjustment.From)
+			goal.Ranges = ranges
+		}
+		switch adjustment.Key {
+		case models.GoalKeyCalories:
+			goal.CaloriesGoal = int(adjustment.To)
+		case models.GoalKeyProteins:
+			goal.ProteinsGoal = int(adjustment.To)
+		case models.GoalKeyFats:
+			goal.FatsGoal = int(adjustment.To)
+		case models.GoalKeyCarbohydrates:
+			goal.CarbsGoal = int(adjustment.To)
+		default:
+			goals := make(models.NutrientAmounts, len(goal.NutrientGoals)+1)
+			for key, value := range goal.NutrientGoals {
+				goals[key] = value
+			}
+			goals[adjustment.Key] = adjustment.To
+			goal.NutrientGoals = goals
+		}
+	}
+}
+
+// roundGoal rounds calories and macros to whole numbers as they are stored,
+// other nutrients to the stored precision.
+func roundGoal(key string, value float64) float64 {
+	if isMacroKey(key) {
+		return math.Round(value)
+	}
+	return models.NutrientAmounts{key: value}.Rounded()[key]
+}
+
+func isMacroKey(key string) bool {
+	switch key {
+	case models.GoalKeyCalories, models.GoalKeyProteins, models.GoalKeyFats, models.GoalKeyCarbohydrates:
+		return true
+	}
+	return false
+}
+
+func knownKey(key string) bool {
+	if isMacroKey(key) {
+		return true
+	}
+	_, ok := nutrients.Find(key)
+	return ok
+}
+
+// sortedKeys keeps the order of adjustments and reasons stable.
+func sortedKeys(amounts models.NutrientAmounts) []string {
+	keys := make([]string, 0, len(amounts))
+	for key := range amounts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,292 @@
+package progression
+
+import (
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"reflect"
+	"testing"
+)
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func testGoal() models.NutritionGoal {
+	return models.NutritionGoal{
+		CaloriesGoal:  2000,
+		ProteinsGoal:  75,
+		FatsGoal:      65,
+		CarbsGoal:     250,
+		NutrientGoals: models.NutrientAmounts{nutrients.Fiber: 25},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.DefaultGoalProgressionPolicy(1)
+
+	tests := []struct {
+		name    string
+		change  func(policy *models.GoalProgressionPolicy)
+		wantErr bool
+	}{
+		{"default policy", func(policy *models.GoalProgressionPolicy) {}, false},
+		{"streak of one day", func(policy *models.GoalProgressionPolicy) { policy.RequiredStreak = 1 }, false},
+		{"streak of zero days", func(policy *models.GoalProgressionPolicy) { policy.RequiredStreak = 0 }, true},
+		{"full tolerance", func(policy *models.GoalProgressionPolicy) { policy.Tolerance = 1 }, false},
+		{"no tolerance", func(policy *models.GoalProgressionPolicy) { policy.Tolerance = 0 }, true},
+		{"tolerance above one", func(policy *models.GoalProgressionPolicy) { policy.Tolerance = 1.1 }, true},
+		{"negative step", func(policy *models.GoalProgressionPolicy) {
+			policy.Steps = models.NutrientAmounts{models.GoalKeyCalories: -10}
+		}, false},
+		{"step removing the whole goal", func(policy *models.GoalProgressionPolicy) {
+			policy.Steps = models.NutrientAmounts{models.GoalKeyCalories: -100}
+		}, true},
+		{"step of a nutrient", func(policy *models.GoalProgressionPolicy) {
+			policy.Steps = models.NutrientAmounts{nutrients.Fiber: 10}
+		}, false},
+		{"step of an unknown goal", func(policy *models.GoalProgressionPolicy) {
+			policy.Steps = models.NutrientAmounts{"happiness": 10}
+		}, true},
+		{"ceiling", func(policy *models.GoalProgressionPolicy) {
+			policy.Ceilings = models.NutrientAmounts{models.GoalKeyCalories: 2500}
+		}, false},
+		{"negative ceiling", func(policy *models.GoalProgressionPolicy) {
+			policy.Ceilings = models.NutrientAmounts{models.GoalKeyCalories: -1}
+		}, true},
+		{"ceiling of an unknown goal", func(policy *models.GoalProgressionPolicy) {
+			policy.Ceilings = models.NutrientAmounts{"happiness": 10}
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := valid
+			tt.change(&policy)
+			if err := Validate(policy); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy := func(change func(policy *models.GoalProgressionPolicy)) models.GoalProgressionPolicy {
+		policy := models.DefaultGoalProgressionPolicy(1)
+		policy.Steps = models.NutrientAmounts{models.GoalKeyCalories: 5}
+		change(&policy)
+		return policy
+	}
+
+	tests := []struct {
+		name         string
+		policy       models.GoalProgressionPolicy
+		streak       int
+		want         []models.GoalAdjustment
+		wantApproval bool
+	}{
+		{
+			name:   "disabled",
+			policy: policy(func(policy *models.GoalProgressionPolicy) { policy.Enabled = false }),
+			streak: 30,
+		},
+		{
+			name:   "streak below the threshold",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {}),
+			streak: 6,
+		},
+		{
+			name:   "streak at the threshold",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {}),
+			streak: 7,
+			want:   []models.GoalAdjustment{{Key: models.GoalKeyCalories, From: 2000, To: 2100}},
+		},
+		{
+			name:   "streak above the threshold",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {}),
+			streak: 20,
+			want:   []models.GoalAdjustment{{Key: models.GoalKeyCalories, From: 2000, To: 2100}},
+		},
+		{
+			name:   "default policy rounds macros",
+			policy: models.DefaultGoalProgressionPolicy(1),
+			streak: 7,
+			want: []models.GoalAdjustment{
+				{Key: models.GoalKeyCalories, From: 2000, To: 2100},
+				{Key: models.GoalKeyCarbohydrates, From: 250, To: 263},
+				{Key: models.GoalKeyFats, From: 65, To: 68},
+				{Key: models.GoalKeyProteins, From: 75, To: 79},
+			},
+		},
+		{
+			name: "capped at the ceiling",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Ceilings = models.NutrientAmounts{models.GoalKeyCalories: 2050}
+			}),
+			streak: 7,
+			want:   []models.GoalAdjustment{{Key: models.GoalKeyCalories, From: 2000, To: 2050}},
+		},
+		{
+			name: "at the ceiling",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Ceilings = models.NutrientAmounts{models.GoalKeyCalories: 2000}
+			}),
+			streak: 7,
+		},
+		{
+			name: "above the ceiling is not lowered",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Ceilings = models.NutrientAmounts{models.GoalKeyCalories: 1800}
+			}),
+			streak: 7,
+		},
+		{
+			name: "negative step ignores the ceiling",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Steps = models.NutrientAmounts{models.GoalKeyCalories: -10}
+				policy.Ceilings = models.NutrientAmounts{models.GoalKeyCalories: 1000}
+			}),
+			streak: 7,
+			want:   []models.GoalAdjustment{{Key: models.GoalKeyCalories, From: 2000, To: 1800}},
+		},
+		{
+			name: "nutrient goal",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Steps = models.NutrientAmounts{nutrients.Fiber: 10}
+			}),
+			streak: 7,
+			want:   []models.GoalAdjustment{{Key: nutrients.Fiber, From: 25, To: 27.5}},
+		},
+		{
+			name: "goal that is not set",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Steps = models.NutrientAmounts{nutrients.Sodium: 10}
+			}),
+			streak: 7,
+		},
+		{
+			name: "step too small to change a macro",
+			policy: policy(func(policy *models.GoalProgressionPolicy) {
+				policy.Steps = models.NutrientAmounts{models.GoalKeyProteins: 0.1}
+			}),
+			streak: 7,
+		},
+		{
+			name:         "requires approval",
+			policy:       policy(func(policy *models.GoalProgressionPolicy) { policy.RequireApproval = true }),
+			streak:       7,
+			want:         []models.GoalAdjustment{{Key: models.GoalKeyCalories, From: 2000, To: 2100}},
+			wantApproval: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := Evaluate(tt.policy, testGoal(), tt.streak)
+			if !reflect.DeepEqual(decision.Adjustments, tt.want) {
+				t.Errorf("Evaluate() adjustments = %v, want %v", decision.Adjustments, tt.want)
+			}
+			if decision.Changes() != (len(tt.want) > 0) {
+				t.Errorf("Evaluate() changes = %v, want %v", decision.Changes(), len(tt.want) > 0)
+			}
+			if decision.NeedsApproval != tt.wantApproval {
+				t.Errorf("Evaluate() needs approval = %v, want %v", decision.NeedsApproval, tt.wantApproval)
+			}
+			if decision.Reason == "" {
+				t.Error("Evaluate() gave no reason")
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		ranges      models.GoalRanges
+		adjustments []models.GoalAdjustment
+		want        func(goal *models.NutritionGoal)
+	}{
+		{
+			name: "macros",
+			adjustments: []models.GoalAdjustment{
+				{Key: models.GoalKeyCalories, From: 2000, To: 2100},
+				{Key: models.GoalKeyProteins, From: 75, To: 79},
+				{Key: models.GoalKeyFats, From: 65, To: 68},
+				{Key: models.GoalKeyCarbohydrates, From: 250, To: 263},
+			},
+			want: func(goal *models.NutritionGoal) {
+				goal.CaloriesGoal = 2100
+				goal.ProteinsGoal = 79
+				goal.FatsGoal = 68
+				goal.CarbsGoal = 263
+			},
+		},
+		{
+			name:        "nutrient goal",
+			adjustments: []models.GoalAdjustment{{Key: nutrients.Fiber, From: 25, To: 27.5}},
+			want: func(goal *models.NutritionGoal) {
+				goal.NutrientGoals = models.NutrientAmounts{nutrients.Fiber: 27.5}
+			},
+		},
+		{
+			name: "range scales with its goal",
+			ranges: models.GoalRanges{
+				models.GoalKeyCalories: {Min: float(1800), Max: float(2200), Tolerance: float(0.05)},
+				models.GoalKeyFats:     {Max: float(80)},
+			},
+			adjustments: []models.GoalAdjustment{{Key: models.GoalKeyCalories, From: 2000, To: 2500}},
+			want: func(goal *models.NutritionGoal) {
+				goal.CaloriesGoal = 2500
+				goal.Ranges = models.GoalRanges{
+					models.GoalKeyCalories: {Min: float(2250), Max: float(2750), Tolerance: float(0.05)},
+					models.GoalKeyFats:     {Max: float(80)},
+				}
+			},
+		},
+		{
+			name: "range of a lowered goal",
+			ranges: models.GoalRanges{
+				models.GoalKeyCarbohydrates: {Min: float(200)},
+			},
+			adjustments: []models.GoalAdjustment{{Key: models.GoalKeyCarbohydrates, From: 250, To: 225}},
+			want: func(goal *models.NutritionGoal) {
+				goal.CarbsGoal = 225
+				goal.Ranges = models.GoalRanges{
+					models.GoalKeyCarbohydrates: {Min: float(180)},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := testGoal()
+			goal.Ranges = tt.ranges
+			want := testGoal()
+			want.Ranges = tt.ranges
+			tt.want(&want)
+
+			Apply(&goal, tt.adjustments)
+			if !reflect.DeepEqual(goal, want) {
+				t.Errorf("Apply() = %+v, want %+v", goal, want)
+			}
+		})
+	}
+}
+
+func TestApplyKeepsTheOldGoal(t *testing.T) {
+	// The old goal version stays in the history, so Apply must not share its maps
+	old := testGoal()
+	old.Ranges = models.GoalRanges{models.GoalKeyCalories: {Min: float(1800)}}
+	goal := old
+
+	Apply(&goal, []models.GoalAdjustment{
+		{Key: models.GoalKeyCalories, From: 2000, To: 2200},
+		{Key: nutrients.Fiber, From: 25, To: 30},
+	})
+	if *old.Ranges[models.GoalKeyCalories].Min != 1800 {
+		t.Errorf("old range changed to %v", *old.Ranges[models.GoalKeyCalories].Min)
+	}
+	if old.NutrientGoals[nutrients.Fiber] != 25 {
+		t.Errorf("old fiber goal changed to %v", old.NutrientGoals[nutrients.Fiber])
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	controllers "BAZ/Nutritracker/controllers"
 	middleware "BAZ/Nutritracker/middleware"
+	"BAZ/Nutritracker/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,11 +19,16 @@ func Routes(router *gin.RouterGroup) {
 	// auth routes (no auth required)
 	router.POST("/login", controllers.UserLogin)
 	router.POST("/register", controllers.UserRegister)
+	router.POST("/token/refresh", controllers.RefreshToken)
 
 	// protected routes (require auth)
 	auth := router.Group("/")
 	auth.Use(middleware.RequireAuth)
 	{
+		// session routes
+		auth.POST("/logout", controllers.Logout)
+		auth.POST("/logout-all", controllers.LogoutAll)
+
 		// user routes
 		auth.PUT("/update", controllers.UpdateUser)
 		auth.DELETE("/delete", controllers.DeleteUser)
@@ -32,15 +38,52 @@ func Routes(router *gin.RouterGroup) {
 		auth.POST("/createnutrilog", controllers.CreateNutrilog)
 		auth.GET("/getnutrilog/:id", controllers.GetNutrilogById)
 		auth.GET("/getallnutrilogs", controllers.GetNutrilogs)
+		auth.GET("/nutrilogs", controllers.ListNutrilogs)
 		auth.PUT("/updatenutrilog/:id", controllers.UpdateNutrilogById)
 		auth.DELETE("/deletenutrilog/:id", controllers.DeleteNutrilogById)
 		auth.GET("/getnutrilogs/:user_id", controllers.GetNutrilogsByUserAndDate)
+		auth.POST("/nutrilogs/copy", controllers.CopyMeal)
+
+		// saved meal routes
+		auth.GET("/savedmeals", controllers.GetSavedMeals)
+		auth.POST("/savedmeals", controllers.CreateSavedMeal)
+		auth.DELETE("/savedmeals/:id", controllers.DeleteSavedMeal)
+		auth.POST("/savedmeals/:id/log", controllers.LogSavedMeal)
+
+		// food catalog routes
+		auth.GET("/nutrients", controllers.GetNutrients)
+		auth.GET("/foods", controllers.SearchFoods)
+		auth.GET("/foods/:id", controllers.GetFood)
+		auth.POST("/foods", controllers.CreateFood)
+		auth.GET("/food/barcode/:code", controllers.LookupBarcode)
+		auth.POST("/food/barcode/:code", controllers.SubmitBarcodeProduct)
+
+		// recipe routes
+		auth.GET("/recipes", controllers.GetRecipes)
+		auth.POST("/recipes", controllers.CreateRecipe)
+		auth.GET("/recipes/:id", controllers.GetRecipe)
+		auth.PUT("/recipes/:id", controllers.UpdateRecipe)
+		auth.DELETE("/recipes/:id", controllers.DeleteRecipe)
 
 		// nutrition goal routes
 		auth.POST("/createnutritiongoal", controllers.CreateNutritionGoal)
 		auth.GET("/getnutritiongoal/:user_id", controllers.GetActiveNutritionGoal)
 		auth.PUT("/updatenutritiongoal/:id", controllers.UpdateNutritionGoal)
 		auth.POST("/checkgoalprogress/:user_id", controllers.CheckAndUpdateGoalProgress)
+		auth.GET("/goalchanges", controllers.GetGoalChanges)
+		auth.GET("/goals/history", controllers.GetGoalHistory)
+		auth.GET("/goals/suggest", controllers.SuggestNutritionGoal)
+		auth.POST("/goals/suggest/accept", controllers.AcceptSuggestedGoal)
+
+		// measurement routes
+		auth.POST("/measurements", controllers.CreateMeasurement)
+		auth.GET("/measurements", controllers.GetMeasurements)
+		auth.PUT("/measurements/:id", controllers.UpdateMeasurement)
+		auth.DELETE("/measurements/:id", controllers.DeleteMeasurement)
+
+		// body metrics used for goal suggestions
+		auth.GET("/bodymetrics/:user_id", controllers.GetBodyMetrics)
+		auth.PUT("/bodymetrics/:user_id", controllers.UpdateBodyMetrics)
 
 		// motivational message routes
 		auth.POST("/createmotivationalmessage", controllers.CreateMotivationalMessage)
@@ -49,5 +92,98 @@ func Routes(router *gin.RouterGroup) {
 		auth.GET("/timedmotivationalmessages/:user_id", controllers.GetTimedMotivationalMessages)
 		auth.PUT("/markmessageasread/:id", controllers.MarkMessageAsRead)
 		auth.DELETE("/deletemotivationalmessage/:id", controllers.DeleteMotivationalMessage)
+
+		// guardian routes
+		patient := auth.Group("/")
+		patient.Use(middleware.RequireRole(models.RolePatient))
+		{
+			patient.POST("/guardians/invitations", controllers.CreateGuardianInvitation)
+			patient.GET("/guardians/invitations", controllers.GetGuardianInvitations)
+			patient.DELETE("/guardians/invitations/:id", controllers.DeleteGuardianInvitation)
+			patient.GET("/guardians", controllers.GetGuardians)
+			patient.PUT("/guardians/:id/sharing", controllers.UpdateGuardianSharing)
+		}
+		guardian := auth.Group("/")
+		guardian.Use(middleware.RequireRole(models.RoleGuardian))
+		{
+			guardian.POST("/guardians/redeem", controllers.RedeemGuardianInvitation)
+			guardian.GET("/patients", controllers.GetPatients)
+
+			// read-only guardian dashboard
+			guardian.GET("/patients/:patient_id/summary", controllers.GetPatientDailySummary)
+			guardian.GET("/patients/:patient_id/nutrilogs", controllers.GetPatientNutrilogs)
+			guardian.GET("/patients/:patient_id/goal", controllers.GetPatientNutritionGoal)
+			guardian.GET("/patients/:patient_id/measurements", controllers.GetPatientMeasurements)
+
+			// goal changes waiting for the guardian's approval
+			guardian.GET("/patients/:patient_id/goalchanges", controllers.GetPatientGoalChanges)
+			guardian.POST("/patients/:patient_id/goalchanges/:id/approve", controllers.ApprovePatientGoalChange)
+			guardian.POST("/patients/:patient_id/goalchanges/:id/reject", controllers.RejectPatientGoalChange)
+		}
+		auth.DELETE("/guardians/:id", controllers.RevokeGuardianLink)
+
+		// buddy routes
+		auth.POST("/buddies/requests", controllers.SendBuddyRequest)
+		auth.GET("/buddies/requests", controllers.GetBuddyRequests)
+		auth.PUT("/buddies/requests/:id", controllers.RespondToBuddyRequest)
+		auth.GET("/buddies", controllers.GetBuddies)
+		auth.GET("/buddies/:user_id", controllers.GetBuddyProgress)
+		auth.DELETE("/buddies/:user_id", controllers.RemoveBuddy)
+		auth.POST("/buddies/:user_id/encourage", controllers.SendEncouragement)
+		auth.POST("/buddies/:user_id/block", controllers.BlockUser)
+		auth.DELETE("/buddies/:user_id/block", controllers.UnblockUser)
+
+		// stats routes
+		auth.GET("/stats", controllers.GetStats)
+		auth.GET("/stats/aggregate", controllers.GetAggregateStats)
+
+		// achievement routes
+		auth.GET("/achievements", controllers.GetAchievements)
+		auth.GET("/achievements/earned", controllers.GetEarnedAchievements)
+
+		// notification routes
+		auth.GET("/notifications", controllers.GetNotifications)
+		auth.PUT("/notifications/:id/read", controllers.MarkNotificationAsRead)
+
+		// case routes
+		clinician := auth.Group("/")
+		clinician.Use(middleware.RequireRole(models.RoleClinician, models.RoleAdmin))
+		{
+			clinician.GET("/cases/rules/:user_id", controllers.GetCaseRuleSettings)
+			clinician.PUT("/cases/rules/:user_id", controllers.UpdateCaseRuleSettings)
+			clinician.GET("/goals/policy/:user_id", controllers.GetGoalProgressionPolicy)
+			clinician.PUT("/goals/policy/:user_id", controllers.UpdateGoalProgressionPolicy)
+			clinician.GET("/goals/changes/:user_id", controllers.GetUserGoalChanges)
+			clinician.POST("/goals/changes/:user_id/:id/approve", controllers.ApproveGoalChange)
+			clinician.POST("/goals/changes/:user_id/:id/reject", controllers.RejectGoalChange)
+			clinician.GET("/cases", controllers.GetCases)
+			clinician.POST("/cases", controllers.CreateCase)
+			clinician.GET("/cases/:id", controllers.GetCase)
+			clinician.PUT("/cases/:id/assign", controllers.AssignCase)
+			clinician.POST("/cases/:id/comments", controllers.AddCaseComment)
+			clinician.PUT("/cases/:id/status", controllers.UpdateCaseStatus)
+		}
+	}
+}
+
+func AdminRoutes(router *gin.RouterGroup) {
+	admin := router.Group("/")
+	admin.Use(middleware.RequireAuth, middleware.RequireRole(models.RoleAdmin))
+	{
+		// user management
+		admin.GET("/users", controllers.AdminListUsers)
+		admin.GET("/users/:id", controllers.AdminGetUser)
+		admin.PUT("/users/:id/role", controllers.AdminUpdateUserRole)
+		admin.DELETE("/users/:id", controllers.AdminDeleteUser)
+
+		// nutrition goal management
+		admin.GET("/users/:id/goals", controllers.AdminGetUserGoals)
+		admin.POST("/users/:id/goals", controllers.AdminSetUserGoal)
+
+		// motivational message catalog
+		admin.GET("/messagetemplates", controllers.AdminListMessageTemplates)
+		admin.POST("/messagetemplates", controllers.AdminCreateMessageTemplate)
+		admin.PUT("/messagetemplates/:id", controllers.AdminUpdateMessageTemplate)
+		admin.DELETE("/messagetemplates/:id", controllers.AdminDeleteMessageTemplate)
 	}
 }
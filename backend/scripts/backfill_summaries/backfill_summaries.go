@@ -0,0 +1,60 @@
+package main
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"flag"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Usage: go run ./scripts/backfill_summaries [-user <id>]
+//
+// Recomputes the daily summaries of every user, or of one user, from their
+// nutrilogs and refreshes their streak. Existing summaries are replaced and
+// each day is evaluated against the version of the user's goal that was in
+// effect on that day.
+
+func init() {
+	initializers.LoadEnvVariables()
+	initializers.ConnectDB()
+	initializers.SyncDatabase()
+}
+
+func main() {
+	userID := flag.Uint("user", 0, "only backfill this user")
+	flag.Parse()
+
+	// Check if DB is nil (database connection failed)
+	if initializers.DB == nil {
+		log.Fatal("Database connection not available")
+	}
+
+	var userIDs []uint
+	query := initializers.DB.Model(&models.User{})
+	if *userID != 0 {
+		query = query.Where("id = ?", *userID)
+	}
+	if err := query.Pluck("id", &userIDs).Error; err != nil {
+		log.Fatal("Error fetching users:", err)
+	}
+
+	failed := 0
+	for _, id := range userIDs {
+		err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+			if err := stats.RebuildDailySummaries(tx, id); err != nil {
+				return err
+			}
+			return stats.RefreshStreak(tx, id)
+		})
+		if err != nil {
+			log.Printf("Error backfilling daily summaries for user %d: %v", id, err)
+			failed++
+		}
+	}
+
+	fmt.Printf("Backfilled daily summaries for %d users (%d failed)\n", len(userIDs)-failed, failed)
+}
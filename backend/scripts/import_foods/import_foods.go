@@ -0,0 +1,80 @@
+package main
+
+import (
+	"BAZ/Nutritracker/foodimport"
+	"BAZ/Nutritracker/initializers"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Usage:
+//
+//	go run ./scripts/import_foods -source off -file en.openfoodfacts.org.products.csv.gz
+//	go run ./scripts/import_foods -source off -file openfoodfacts-products.jsonl.gz
+//	go run ./scripts/import_foods -source usda -dir FoodData_Central_csv_2024-10-31
+//
+// Imports an Open Food Facts export or a FoodData Central CSV download into
+// the food catalog. Files are streamed, so dumps of several gigabytes are
+// fine, and running an import again updates the foods it imported before.
+// Skipped rows are counted per reason and listed in the -report file.
+
+func init() {
+	initializers.LoadEnvVariables()
+	initializers.ConnectDB()
+	initializers.SyncDatabase()
+}
+
+func main() {
+	source := flag.String("source", "", "off or usda")
+	file := flag.String("file", "", "Open Food Facts CSV or JSONL export, optionally gzipped")
+	dir := flag.String("dir", "", "directory of the FoodData Central CSV files")
+	batchSize := flag.Int("batch", foodimport.DefaultBatchSize, "rows written per batch")
+	reportPath := flag.String("report", "", "write skipped rows to this CSV file")
+	flag.Parse()
+
+	// Check if DB is nil (database connection failed)
+	if initializers.DB == nil {
+		log.Fatal("Database connection not available")
+	}
+
+	importer := &foodimport.Importer{DB: initializers.DB, BatchSize: *batchSize}
+	if *reportPath != "" {
+		report, err := os.Create(*reportPath)
+		if err != nil {
+			log.Fatal("Error creating report:", err)
+		}
+		defer report.Close()
+		importer.Report = csv.NewWriter(report)
+		importer.Report.Write([]string{"file", "row", "id", "reason"})
+		defer importer.Report.Flush()
+	}
+
+	var err error
+	switch *source {
+	case "off":
+		if *file == "" {
+			log.Fatal("-file is required for Open Food Facts")
+		}
+		err = importer.ImportOpenFoodFacts(*file)
+	case "usda":
+		if *dir == "" {
+			log.Fatal("-dir is required for USDA FoodData Central")
+		}
+		err = importer.ImportUSDA(*dir)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	fmt.Println(importer.Summary)
+	if err != nil {
+		log.Printf("Import stopped: %v", err)
+		if importer.Report != nil {
+			importer.Report.Flush()
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/stats"
+	"fmt"
+	"log"
+)
+
+// Usage: go run ./scripts/rebuild_stats
+//
+// Recomputes the Stats record of every user from their nutrilogs, cases and
+// achievements.
+
+func init() {
+	initializers.LoadEnvVariables()
+	initializers.ConnectDB()
+	initializers.SyncDatabase()
+}
+
+func main() {
+	// Check if DB is nil (database connection failed)
+	if initializers.DB == nil {
+		log.Fatal("Database connection not available")
+	}
+
+	var users []models.User
+	if err := initializers.DB.Find(&users).Error; err != nil {
+		log.Fatal("Error fetching users:", err)
+	}
+
+	failed := 0
+	for _, user := range users {
+		if err := stats.Rebuild(initializers.DB, user.ID); err != nil {
+			log.Printf("Error rebuilding stats for user %d: %v", user.ID, err)
+			failed++
+		}
+	}
+
+	fmt.Printf("Rebuilt stats for %d users (%d failed)\n", len(users)-failed, failed)
+}
@@ -7,7 +7,8 @@ import (
 	"log"
 )
 
-// Sample motivational messages for different meal types
+// Sample motivational messages for different meal types, used to fill an
+// empty message catalog
 var breakfastMessages = []string{
 	"Start your day right with a nutritious breakfast!",
 	"Good morning! Remember that breakfast is the most important meal of the day.",
@@ -49,6 +50,47 @@ func init() {
 	initializers.SyncDatabase()
 }
 
+// defaultSchedule is the time of day the default messages of each type are shown.
+var defaultSchedule = map[string]string{
+	"breakfast": "08:00", // Scheduled for 8 AM
+	"lunch":     "12:30", // Scheduled for 12:30 PM
+	"dinner":    "18:30", // Scheduled for 6:30 PM
+	"general":   "",      // No specific time for general messages
+}
+
+// seedTemplates fills an empty message catalog with the sample messages, so
+// admins can edit them through the message template routes.
+func seedTemplates() error {
+	var count int64
+	if err := initializers.DB.Model(&models.MessageTemplate{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	samples := map[string][]string{
+		"breakfast": breakfastMessages,
+		"lunch":     lunchMessages,
+		"dinner":    dinnerMessages,
+		"general":   generalMessages,
+	}
+	for _, messageType := range []string{"breakfast", "lunch", "dinner", "general"} {
+		for _, msg := range samples[messageType] {
+			template := models.MessageTemplate{
+				Message:      msg,
+				MessageType:  messageType,
+				ScheduledFor: defaultSchedule[messageType],
+				IsActive:     true,
+			}
+			if err := initializers.DB.Create(&template).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func main() {
 	// Check if DB is nil (database connection failed)
 	if initializers.DB == nil {
@@ -56,6 +98,23 @@ func main() {
 		return
 	}
 
+	if err := seedTemplates(); err != nil {
+		log.Fatal("Error seeding message templates:", err)
+		return
+	}
+
+	// Messages are copied from the active templates of the catalog
+	var templates []models.MessageTemplate
+	if err := initializers.DB.Where("is_active = ?", true).Order("id").Find(&templates).Error; err != nil {
+		log.Fatal("Error fetching message templates:", err)
+		return
+	}
+
+	if len(templates) == 0 {
+		log.Println("No active message templates found.")
+		return
+	}
+
 	// Get all users to create messages for
 	var users []models.User
 	result := initializers.DB.Find(&users)
@@ -70,69 +129,20 @@ func main() {
 		return
 	}
 
-	// For each user, create sample messages
+	// For each user, copy every active template into their feed
 	for _, user := range users {
-		// Create breakfast messages
-		for i, msg := range breakfastMessages {
-			message := models.MotivationalMessage{
-				Message:      msg,
-				MessageType:  "breakfast",
-				UserID:       user.ID,
-				IsRead:       false,
-				ScheduledFor: "08:00", // Scheduled for 8 AM
-			}
-			
-			result := initializers.DB.Create(&message)
-			if result.Error != nil {
-				log.Printf("Error creating breakfast message %d for user %s: %v", i, user.Username, result.Error)
-			}
-		}
-
-		// Create lunch messages
-		for i, msg := range lunchMessages {
-			message := models.MotivationalMessage{
-				Message:      msg,
-				MessageType:  "lunch",
-				UserID:       user.ID,
-				IsRead:       false,
-				ScheduledFor: "12:30", // Scheduled for 12:30 PM
-			}
-			
-			result := initializers.DB.Create(&message)
-			if result.Error != nil {
-				log.Printf("Error creating lunch message %d for user %s: %v", i, user.Username, result.Error)
-			}
-		}
-
-		// Create dinner messages
-		for i, msg := range dinnerMessages {
+		for _, template := range templates {
 			message := models.MotivationalMessage{
-				Message:      msg,
-				MessageType:  "dinner",
+				Message:      template.Message,
+				MessageType:  template.MessageType,
 				UserID:       user.ID,
 				IsRead:       false,
-				ScheduledFor: "18:30", // Scheduled for 6:30 PM
-			}
-			
-			result := initializers.DB.Create(&message)
-			if result.Error != nil {
-				log.Printf("Error creating dinner message %d for user %s: %v", i, user.Username, result.Error)
+				ScheduledFor: template.ScheduledFor,
 			}
-		}
 
-		// Create general messages
-		for i, msg := range generalMessages {
-			message := models.MotivationalMessage{
-				Message:      msg,
-				MessageType:  "general",
-				UserID:       user.ID,
-				IsRead:       false,
-				ScheduledFor: "", // No specific time for general messages
-			}
-			
 			result := initializers.DB.Create(&message)
 			if result.Error != nil {
-				log.Printf("Error creating general message %d for user %s: %v", i, user.Username, result.Error)
+				log.Printf("Error creating %s message from template %d for user %s: %v", template.MessageType, template.ID, user.Username, result.Error)
 			}
 		}
 	}
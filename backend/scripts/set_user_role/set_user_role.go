@@ -0,0 +1,46 @@
+package main
+
+import (
+	"BAZ/Nutritracker/initializers"
+	"BAZ/Nutritracker/models"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Usage: go run ./scripts/set_user_role <email> <role>
+//
+// Used to bootstrap the first admin, after that roles can be managed through
+// the /api/v1/admin routes.
+
+func init() {
+	initializers.LoadEnvVariables()
+	initializers.ConnectDB()
+	initializers.SyncDatabase()
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		log.Fatal("Usage: set_user_role <email> <role>")
+	}
+	email, role := os.Args[1], os.Args[2]
+
+	if !models.IsValidRole(role) {
+		log.Fatalf("Unknown role %q", role)
+	}
+
+	// Check if DB is nil (database connection failed)
+	if initializers.DB == nil {
+		log.Fatal("Database connection not available")
+	}
+
+	result := initializers.DB.Model(&models.User{}).Where("email = ?", email).Update("role", role)
+	if result.Error != nil {
+		log.Fatal("Error updating role:", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		log.Fatalf("No user found with email %s", email)
+	}
+
+	fmt.Printf("User %s now has role %s\n", email, role)
+}
@@ -0,0 +1,170 @@
+package stats
+
+import (
+	"BAZ/Nutritracker/models"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Granularities nutrilogs can be aggregated by. Weeks start on Monday.
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// bucketColumns are the SQL expressions giving the first day of the bucket a
+// day falls in.
+var bucketColumns = map[string]string{
+	GranularityDay:   "date",
+	GranularityWeek:  "DATE_SUB(date, INTERVAL WEEKDAY(date) DAY)",
+	GranularityMonth: "DATE_FORMAT(date, '%Y-%m-01')",
+}
+
+// MacroSplit is the share of the energy from macros that comes from each one,
+// in percent. Proteins and carbohydrates count 4 kcal per gram, fats 9.
+type MacroSplit struct {
+	Proteins      float64 `json:"proteins"`
+	Fats          float64 `json:"fats"`
+	Carbohydrates float64 `json:"carbohydrates"`
+}
+
+// Bucket is the aggregate of the nutrilogs of a user over one day, week or month.
+type Bucket struct {
+	Start         models.Date `json:"start"`
+	End           models.Date `json:"end"`
+	MealCount     int         `json:"meal_count"`
+	DaysLogged    int         `json:"days_logged"`
+	Calories      float64     `json:"calories"`
+	Proteins      float64     `json:"proteins"`
+	Fats          float64     `json:"fats"`
+	Carbohydrates float64     `json:"carbohydrates"`
+
+	// Averages are per day with at least one nutrilog
+	AverageCalories      float64 `json:"average_calories"`
+	AverageProteins      float64 `json:"average_proteins"`
+	AverageFats          float64 `json:"average_fats"`
+	AverageCarbohydrates float64 `json:"average_carbohydrates"`
+
+	MacroSplit MacroSplit `json:"macro_split"`
+
+	// GoalDaysAchieved counts the logged days that reached the goal of that
+	// day. GoalAdherence is their share of the logged days that had a goal,
+	// nil when none had.
+	GoalDaysAchieved int      `json:"goal_days_achieved"`
+	GoalAdherence    *float64 `json:"goal_adherence"`
+}
+
+// BucketStart returns the first day of the bucket that day falls in.
+func BucketStart(day models.Date, granularity string) models.Date {
+	t := day.Time(time.UTC)
+	switch granularity {
+	case GranularityWeek:
+		// Monday is the first day of the week
+		return day.AddDays(-((int(t.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return models.DateOf(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC))
+	}
+	return day
+}
+
+// bucketEnd returns the last day of the bucket starting on start.
+func bucketEnd(start models.Date, granularity string) models.Date {
+	switch granularity {
+	case GranularityWeek:
+		return start.AddDays(6)
+	case GranularityMonth:
+		return models.DateOf(start.Time(time.UTC).AddDate(0, 1, -1))
+	}
+	return start
+}
+
+// Aggregate sums the daily summaries of a user between two days (inclusive)
+// per day, week or month. Days are the days of the user's timezone the meals
+// were logged in. Buckets without nutrilogs are included so charts have no
+// gaps.
+func Aggregate(tx *gorm.DB, userID uint, granularity string, from models.Date, to models.Date) ([]Bucket, error) {
+	bucketColumn, ok := bucketColumns[granularity]
+	if !ok {
+		return nil, errors.New("unknown granularity")
+	}
+
+	var rows []struct {
+		Bucket           models.Date
+		MealCount        int
+		DaysLogged       int
+		Calories         float64
+		Proteins         float64
+		Fats             float64
+		Carbohydrates    float64
+		GoalDays         int
+		GoalDaysAchieved int
+	}
+	err := tx.Model(&models.DailySummary{}).
+		Select(bucketColumn+" AS bucket, SUM(meal_count) AS meal_count, COUNT(*) AS days_logged, "+
+			"SUM(calories) AS calories, SUM(proteins) AS proteins, SUM(fats) AS fats, SUM(carbohydrates) AS carbohydrates, "+
+			"COUNT(nutrition_goal_id) AS goal_days, SUM(CASE WHEN goal_achieved THEN 1 ELSE 0 END) AS goal_days_achieved").
+		Where("user_id = ? AND date >= ? AND date <= ?", userID, from, to).
+		Group("bucket").
+		Order("bucket").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := []Bucket{}
+	next := 0
+	for start := BucketStart(from, granularity); start <= to; start = bucketEnd(start, granularity).AddDays(1) {
+		bucket := Bucket{Start: start, End: bucketEnd(start, granularity)}
+		if next < len(rows) && rows[next].Bucket == start {
+			row := rows[next]
+			next++
+
+			bucket.MealCount = row.MealCount
+			bucket.DaysLogged = row.DaysLogged
+			bucket.Calories = round(row.Calories)
+			bucket.Proteins = round(row.Proteins)
+			bucket.Fats = round(row.Fats)
+			bucket.Carbohydrates = round(row.Carbohydrates)
+			bucket.GoalDaysAchieved = row.GoalDaysAchieved
+
+			logged := float64(row.DaysLogged)
+			bucket.AverageCalories = round(row.Calories / logged)
+			bucket.AverageProteins = round(row.Proteins / logged)
+			bucket.AverageFats = round(row.Fats / logged)
+			bucket.AverageCarbohydrates = round(row.Carbohydrates / logged)
+
+			if row.GoalDays > 0 {
+				adherence := round(float64(row.GoalDaysAchieved) / float64(row.GoalDays))
+				bucket.GoalAdherence = &adherence
+			}
+		}
+		bucket.MacroSplit = macroSplit(bucket.Proteins, bucket.Fats, bucket.Carbohydrates)
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// macroSplit computes the energy share of each macro.
+func macroSplit(proteins float64, fats float64, carbohydrates float64) MacroSplit {
+	proteinEnergy := proteins * 4
+	fatEnergy := fats * 9
+	carbEnergy := carbohydrates * 4
+	total := proteinEnergy + fatEnergy + carbEnergy
+	if total == 0 {
+		return MacroSplit{}
+	}
+	return MacroSplit{
+		Proteins:      round(proteinEnergy / total * 100),
+		Fats:          round(fatEnergy / total * 100),
+		Carbohydrates: round(carbEnergy / total * 100),
+	}
+}
+
+// round keeps two decimals.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
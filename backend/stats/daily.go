@@ -0,0 +1,210 @@
+package stats
+
+import (
+	"BAZ/Nutritracker/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NutrientTotals is the sum of the nutrients over a set of nutrilogs.
+type NutrientTotals struct {
+	Calories      float64                `json:"calories"`
+	Proteins      float64                `json:"proteins"`
+	Fats          float64                `json:"fats"`
+	Carbohydrates float64                `json:"carbohydrates"`
+	Nutrients     models.NutrientAmounts `json:"nutrients"`
+}
+
+// SumNutrilogs adds up the nutrients of the given nutrilogs.
+func SumNutrilogs(nutrilogs []models.Nutrilog) NutrientTotals {
+	var totals NutrientTotals
+	for _, log := range nutrilogs {
+		totals.Calories += log.Calories
+		totals.Proteins += log.Proteins
+		totals.Fats += log.Fats
+		totals.Carbohydrates += log.Carbohydrates
+		totals.Nutrients = totals.Nutrients.Add(log.Nutrients)
+	}
+	totals.Calories = models.RoundMacro(totals.Calories)
+	totals.Proteins = models.RoundMacro(totals.Proteins)
+	totals.Fats = models.RoundMacro(totals.Fats)
+	totals.Carbohydrates = models.RoundMacro(totals.Carbohydrates)
+	totals.Nutrients = totals.Nutrients.Rounded()
+	return totals
+}
+
+// SummaryTotals returns the totals stored in a daily summary.
+func SummaryTotals(summary models.DailySummary) NutrientTotals {
+	return NutrientTotals{
+		Calories:      summary.Calories,
+		Proteins:      summary.Proteins,
+		Fats:          summary.Fats,
+		Carbohydrates: summary.Carbohydrates,
+		Nutrients:     summary.Nutrients,
+	}
+}
+
+// GoalTolerance returns the share of a goal a user has to reach for it to
+// count as achieved, as set by their progression policy.
+func GoalTolerance(tx *gorm.DB, userID uint) float64 {
+	policy := models.DefaultGoalProgressionPolicy(userID)
+	tx.Where("user_id = ?", userID).Limit(1).Find(&policy)
+	return policy.Tolerance
+}
+
+// GoalAchieved reports whether every nutrient of the goal is within its range.
+func GoalAchieved(goal models.NutritionGoal, totals NutrientTotals, tolerance float64) bool {
+	return AllWithin(GoalStatuses(goal, totals, tolerance))
+}
+
+// AllWithin reports whether no nutrient is under or over its range.
+func AllWithin(statuses models.GoalStatuses) bool {
+	for _, status := range statuses {
+		if status.Status != models.GoalStatusWithin {
+			return false
+		}
+	}
+	return true
+}
+
+// summarize builds the summary of one day from its nutrilogs. goal may be nil.
+func summarize(userID uint, date models.Date, nutrilogs []models.Nutrilog, goal *models.NutritionGoal, tolerance float64) models.DailySummary {
+	totals := SumNutrilogs(nutrilogs)
+	summary := models.DailySummary{
+		UserID:        userID,
+		Date:          date,
+		MealCount:     len(nutrilogs),
+		Calories:      totals.Calories,
+		Proteins:      totals.Proteins,
+		Fats:          totals.Fats,
+		Carbohydrates: totals.Carbohydrates,
+		Nutrients:     totals.Nutrients,
+	}
+	if goal != nil {
+		summary.NutritionGoalID = &goal.ID
+		summary.CaloriesGoal = goal.CaloriesGoal
+		summary.ProteinsGoal = goal.ProteinsGoal
+		summary.FatsGoal = goal.FatsGoal
+		summary.CarbsGoal = goal.CarbsGoal
+		summary.NutrientGoals = goal.NutrientGoals
+		summary.GoalStatuses = GoalStatuses(*goal, totals, tolerance)
+		summary.GoalAchieved = AllWithin(summary.GoalStatuses)
+	}
+	return summary
+}
+
+// GoalOn returns the version of the nutrition goal of a user that was in
+// effect on a day, or nil. When versions changed during the day the last one
+// counts.
+func GoalOn(tx *gorm.DB, userID uint, date models.Date) (*models.NutritionGoal, error) {
+	var goals []models.NutritionGoal
+	err := tx.Where("user_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", userID, date, date).
+		Order("effective_from DESC, id DESC").
+		Limit(1).
+		Find(&goals).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(goals) == 0 {
+		return nil, nil
+	}
+	return &goals[0], nil
+}
+
+// goalOnDate picks the version in effect on a day from versions sorted by
+// effective_from and id, like GoalOn.
+func goalOnDate(versions []models.NutritionGoal, date models.Date) *models.NutritionGoal {
+	for i := len(versions) - 1; i >= 0; i-- {
+		version := versions[i]
+		if version.EffectiveFrom <= date && (version.EffectiveTo == nil || *version.EffectiveTo >= date) {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// saveSummary inserts or replaces the summary of a day.
+func saveSummary(tx *gorm.DB, summary models.DailySummary) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"meal_count", "calories", "proteins", "fats", "carbohydrates", "nutrients",
+			"nutrition_goal_id", "calories_goal", "proteins_goal", "fats_goal", "carbs_goal", "nutrient_goals",
+			"goal_statuses", "goal_achieved", "updated_at",
+		}),
+	}).Create(&summary).Error
+}
+
+// RefreshDailySummaries recomputes the summaries of a user for the given days
+// against the goal in effect on each day. Days without nutrilogs lose their
+// summary. Call it inside the transaction that changes the nutrilogs of those
+// days, with both the old and the new day when a nutrilog moves.
+func RefreshDailySummaries(tx *gorm.DB, userID uint, dates ...models.Date) error {
+	tolerance := GoalTolerance(tx, userID)
+
+	done := map[models.Date]bool{}
+	for _, date := range dates {
+		if date == "" || done[date] {
+			continue
+		}
+		done[date] = true
+
+		var nutrilogs []models.Nutrilog
+		if err := tx.Where("user_id = ? AND meal_date = ?", userID, date).Find(&nutrilogs).Error; err != nil {
+			return err
+		}
+		if len(nutrilogs) == 0 {
+			err := tx.Unscoped().Where("user_id = ? AND date = ?", userID, date).Delete(&models.DailySummary{}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		goal, err := GoalOn(tx, userID, date)
+		if err != nil {
+			return err
+		}
+		if err := saveSummary(tx, summarize(userID, date, nutrilogs, goal, tolerance)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebuildDailySummaries recomputes every daily summary of a user from their
+// nutrilogs, against the goal in effect on each day.
+func RebuildDailySummaries(tx *gorm.DB, userID uint) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		var versions []models.NutritionGoal
+		err := tx.Where("user_id = ?", userID).Order("effective_from, id").Find(&versions).Error
+		if err != nil {
+			return err
+		}
+		tolerance := GoalTolerance(tx, userID)
+
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.DailySummary{}).Error; err != nil {
+			return err
+		}
+
+		var nutrilogs []models.Nutrilog
+		err = tx.Where("user_id = ? AND meal_date IS NOT NULL", userID).Order("meal_date").Find(&nutrilogs).Error
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(nutrilogs); {
+			end := start
+			for end < len(nutrilogs) && nutrilogs[end].MealDate == nutrilogs[start].MealDate {
+				end++
+			}
+			date := nutrilogs[start].MealDate
+			summary := summarize(userID, date, nutrilogs[start:end], goalOnDate(versions, date), tolerance)
+			if err := tx.Create(&summary).Error; err != nil {
+				return err
+			}
+			start = end
+		}
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"fmt"
+)
+
+// macroGoalKeys are the goal keys every nutrition goal has.
+var macroGoalKeys = []string{
+	models.GoalKeyCalories,
+	models.GoalKeyProteins,
+	models.GoalKeyFats,
+	models.GoalKeyCarbohydrates,
+}
+
+// GoalRange returns the range a nutrient has to stay in under a goal. An
+// explicit range wins. Otherwise a single goal for calories or a macro is both
+// the minimum and the maximum, so with the tolerance of the user's policy (10%
+// by default) 2000 kcal counts as within from 1800 to 2200 kcal. A single goal
+// for another nutrient is a minimum, or a maximum without tolerance for
+// nutrients whose goal is an upper limit. Returns false when the goal doesn't
+// cover the key.
+func GoalRange(goal models.NutritionGoal, key string) (models.GoalRange, bool) {
+	if goalRange, ok := goal.Ranges[key]; ok {
+		return goalRange, true
+	}
+	target := goal.Target(key)
+	if target == 0 {
+		return models.GoalRange{}, false
+	}
+	if isMacroGoalKey(key) {
+		return models.GoalRange{Min: &target, Max: &target}, true
+	}
+	if nutrient, ok := nutrients.Find(key); ok && nutrient.UpperLimit {
+		noTolerance := 0.0
+		return models.GoalRange{Max: &target, Tolerance: &noTolerance}, true
+	}
+	return models.GoalRange{Min: &target}, true
+}
+
+// GoalStatuses compares the totals of a day to every range of the goal.
+// policyTolerance is the share of a goal that counts as reached in the user's
+// progression policy, it gives the tolerance of ranges that have none.
+func GoalStatuses(goal models.NutritionGoal, totals NutrientTotals, policyTolerance float64) models.GoalStatuses {
+	keys := append([]string{}, macroGoalKeys...)
+	for key := range goal.NutrientGoals {
+		keys = append(keys, key)
+	}
+	for key := range goal.Ranges {
+		keys = append(keys, key)
+	}
+
+	statuses := models.GoalStatuses{}
+	for _, key := range keys {
+		if _, done := statuses[key]; done {
+			continue
+		}
+		goalRange, ok := GoalRange(goal, key)
+		if !ok {
+			continue
+		}
+		tolerance := 1 - policyTolerance
+		if goalRange.Tolerance != nil {
+			tolerance = *goalRange.Tolerance
+		}
+		amount := totalAmount(totals, key)
+		statuses[key] = models.GoalStatus{
+			Amount:    amount,
+			Min:       goalRange.Min,
+			Max:       goalRange.Max,
+			Tolerance: tolerance,
+			Status:    goalRange.Status(amount, tolerance),
+		}
+	}
+	return statuses
+}
+
+// totalAmount returns the total of a goal key.
+func totalAmount(totals NutrientTotals, key string) float64 {
+	switch key {
+	case models.GoalKeyCalories:
+		return totals.Calories
+	case models.GoalKeyProteins:
+		return totals.Proteins
+	case models.GoalKeyFats:
+		return totals.Fats
+	case models.GoalKeyCarbohydrates:
+		return totals.Carbohydrates
+	}
+	return totals.Nutrients[key]
+}
+
+// ValidateGoalRanges checks that ranges only use known goal keys, that their
+// bounds aren't negative or crossed and that tolerances are below 1.
+func ValidateGoalRanges(ranges models.GoalRanges) error {
+	for key, goalRange := range ranges {
+		if _, ok := nutrients.Find(key); !ok && !isMacroGoalKey(key) {
+			return fmt.Errorf("unknown goal %q", key)
+		}
+		if goalRange.Min == nil && goalRange.Max == nil {
+			return fmt.Errorf("range of %s needs a min or a max", key)
+		}
+		if (goalRange.Min != nil && *goalRange.Min < 0) || (goalRange.Max != nil && *goalRange.Max < 0) {
+			return fmt.Errorf("range of %s can't be negative", key)
+		}
+		if goalRange.Min != nil && goalRange.Max != nil && *goalRange.Min > *goalRange.Max {
+			return fmt.Errorf("min of %s is above its max", key)
+		}
+		if goalRange.Tolerance != nil && (*goalRange.Tolerance < 0 || *goalRange.Tolerance >= 1) {
+			return fmt.Errorf("tolerance of %s must be between 0 and 1", key)
+		}
+	}
+	return nil
+}
+
+func isMacroGoalKey(key string) bool {
+	for _, macroKey := range macroGoalKeys {
+		if key == macroKey {
+			return true
+		}
+	}
+	return false
+}
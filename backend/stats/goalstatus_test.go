@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"BAZ/Nutritracker/models"
+	"BAZ/Nutritracker/nutrients"
+	"testing"
+)
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func TestGoalStatuses(t *testing.T) {
+	goal := models.NutritionGoal{
+		CaloriesGoal: 2000,
+		ProteinsGoal: 100,
+		FatsGoal:     70,
+		CarbsGoal:    250,
+		NutrientGoals: models.NutrientAmounts{
+			nutrients.Fiber:  30,
+			nutrients.Sodium: 2300,
+		},
+		Ranges: models.GoalRanges{
+			models.GoalKeyCarbohydrates: {Min: float(200), Max: float(300), Tolerance: float(0)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		amount float64
+		want   string
+	}{
+		{"calories well under", models.GoalKeyCalories, 1500, models.GoalStatusUnder},
+		{"calories at the lower tolerance", models.GoalKeyCalories, 1800, models.GoalStatusWithin},
+		{"calories on the goal", models.GoalKeyCalories, 2000, models.GoalStatusWithin},
+		{"calories at the upper tolerance", models.GoalKeyCalories, 2200, models.GoalStatusWithin},
+		{"calories over", models.GoalKeyCalories, 2201, models.GoalStatusOver},
+		{"proteins over", models.GoalKeyProteins, 120, models.GoalStatusOver},
+		{"fats under", models.GoalKeyFats, 60, models.GoalStatusUnder},
+		{"explicit range within", models.GoalKeyCarbohydrates, 290, models.GoalStatusWithin},
+		{"explicit range over", models.GoalKeyCarbohydrates, 301, models.GoalStatusOver},
+		{"explicit range under", models.GoalKeyCarbohydrates, 199, models.GoalStatusUnder},
+		{"nutrient minimum reached", nutrients.Fiber, 50, models.GoalStatusWithin},
+		{"nutrient minimum missed", nutrients.Fiber, 20, models.GoalStatusUnder},
+		{"upper limit kept", nutrients.Sodium, 2300, models.GoalStatusWithin},
+		{"upper limit passed", nutrients.Sodium, 2301, models.GoalStatusOver},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := NutrientTotals{Nutrients: models.NutrientAmounts{}}
+			switch tt.key {
+			case models.GoalKeyCalories:
+				totals.Calories = tt.amount
+			case models.GoalKeyProteins:
+				totals.Proteins = tt.amount
+			case models.GoalKeyFats:
+				totals.Fats = tt.amount
+			case models.GoalKeyCarbohydrates:
+				totals.Carbohydrates = tt.amount
+			default:
+				totals.Nutrients[tt.key] = tt.amount
+			}
+
+			statuses := GoalStatuses(goal, totals, 0.9)
+			if got := statuses[tt.key].Status; got != tt.want {
+				t.Errorf("status of %s at %v = %s, want %s", tt.key, tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoalRangeDefaults(t *testing.T) {
+	goal := models.NutritionGoal{
+		CaloriesGoal:  2000,
+		NutrientGoals: models.NutrientAmounts{nutrients.Fiber: 30, nutrients.Sugar: 50},
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		wantMin *float64
+		wantMax *float64
+		wantOk  bool
+	}{
+		{"macro goal is a band", models.GoalKeyCalories, float(2000), float(2000), true},
+		{"nutrient goal is a minimum", nutrients.Fiber, float(30), nil, true},
+		{"upper limit is a maximum", nutrients.Sugar, nil, float(50), true},
+		{"no goal", models.GoalKeyProteins, nil, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goalRange, ok := GoalRange(goal, tt.key)
+			if ok != tt.wantOk {
+				t.Fatalf("GoalRange() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !sameBound(goalRange.Min, tt.wantMin) || !sameBound(goalRange.Max, tt.wantMax) {
+				t.Errorf("GoalRange() = %v..%v, want %v..%v", goalRange.Min, goalRange.Max, tt.wantMin, tt.wantMax)
+			}
+		})
+	}
+}
+
+func sameBound(a *float64, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
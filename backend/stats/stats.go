@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"BAZ/Nutritracker/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// upsert writes the given columns of the user's Stats record, creating the
+// record if the user doesn't have one yet.
+func upsert(tx *gorm.DB, userID uint, columns map[string]interface{}) error {
+	record := models.Stats{UserID: userID}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoNothing: true,
+	}).Create(&record).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Stats{}).Where("user_id = ?", userID).Updates(columns).Error
+}
+
+// RefreshCases recounts the active cases of a user. Call it inside the
+// transaction that changes the case table so both stay consistent.
+func RefreshCases(tx *gorm.DB, userID uint) error {
+	var count int64
+	err := tx.Model(&models.Cases{}).
+		Where("user_id = ? AND status IN ?", userID, models.ActiveCaseStatuses).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	return upsert(tx, userID, map[string]interface{}{"cases": count})
+}
+
+// RefreshAchievements recounts the achievements a user has earned.
+func RefreshAchievements(tx *gorm.DB, userID uint) error {
+	var count int64
+	err := tx.Model(&models.Accomplished_achievements{}).Where("user_id = ?", userID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	return upsert(tx, userID, map[string]interface{}{"achievements_gained": count})
+}
+
+// RefreshStreak recomputes the logging streak of a user from their daily
+// summaries, so refresh those first. Days after the user's today, from meals
+// logged ahead, don't count yet.
+func RefreshStreak(tx *gorm.DB, userID uint) error {
+	var user models.User
+	if err := tx.Select("id", "timezone").Limit(1).Find(&user, userID).Error; err != nil {
+		return err
+	}
+	today := models.DateOf(time.Now().In(user.Location()))
+
+	var dates []models.Date
+	err := tx.Model(&models.DailySummary{}).
+		Where("user_id = ? AND date <= ?", userID, today).
+		Order("date DESC").
+		Pluck("date", &dates).Error
+	if err != nil {
+		return err
+	}
+
+	streak, lastLogDate := streakFromDates(dates)
+	return upsert(tx, userID, map[string]interface{}{
+		"streak":        streak,
+		"last_log_date": lastLogDate,
+	})
+}
+
+// Rebuild recomputes every counter of a user from the raw data.
+func Rebuild(tx *gorm.DB, userID uint) error {
+	return tx.Transaction(func(tx *gorm.DB) error {
+		if err := RebuildDailySummaries(tx, userID); err != nil {
+			return err
+		}
+		if err := RefreshStreak(tx, userID); err != nil {
+			return err
+		}
+		if err := RefreshCases(tx, userID); err != nil {
+			return err
+		}
+		return RefreshAchievements(tx, userID)
+	})
+}
+
+// CurrentStreak returns the streak as seen at the given time, which must be in
+// the user's timezone. A streak is still alive if the last meal was logged
+// today or yesterday.
+func CurrentStreak(record models.Stats, now time.Time) int {
+	today := models.DateOf(now)
+	yesterday := today.AddDays(-1)
+	if record.LastLogDate == today || record.LastLogDate == yesterday {
+		return record.Streak
+	}
+	return 0
+}
+
+// streakFromDates counts how many days in a row end at the most recent date.
+// dates must be distinct, newest first.
+func streakFromDates(dates []models.Date) (int, models.Date) {
+	if len(dates) == 0 {
+		return 0, ""
+	}
+
+	streak := 1
+	previous := dates[0]
+	for _, day := range dates[1:] {
+		if previous.AddDays(-1) != day {
+			break
+		}
+		streak++
+		previous = day
+	}
+	return streak, dates[0]
+}
@@ -0,0 +1,65 @@
+package stats
+
+import (
+	"BAZ/Nutritracker/internal/fakedb"
+	"BAZ/Nutritracker/models"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreakFromDates(t *testing.T) {
+	tests := []struct {
+		name       string
+		dates      []models.Date
+		wantStreak int
+		wantLast   models.Date
+	}{
+		{"no days", nil, 0, ""},
+		{"one day", []models.Date{"2026-10-17"}, 1, "2026-10-17"},
+		{"days in a row", []models.Date{"2026-10-17", "2026-10-16", "2026-10-15"}, 3, "2026-10-17"},
+		{"gap", []models.Date{"2026-10-17", "2026-10-16", "2026-10-13"}, 2, "2026-10-17"},
+		{"across a month", []models.Date{"2026-11-01", "2026-10-31"}, 2, "2026-11-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streak, last := streakFromDates(tt.dates)
+			if streak != tt.wantStreak || last != tt.wantLast {
+				t.Errorf("streakFromDates() = %d, %q, want %d, %q", streak, last, tt.wantStreak, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestRefreshStreakIgnoresFutureDays(t *testing.T) {
+	// Kiritimati is 14 hours ahead of UTC, so its today is often the UTC tomorrow
+	db, gormDB, err := fakedb.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.On("users", []string{"id", "timezone"}, []driver.Value{int64(1), "Pacific/Kiritimati"})
+	db.On("daily_summaries", []string{"date"})
+
+	if err := RefreshStreak(gormDB, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	today := models.DateOf(time.Now().In(loc))
+
+	for _, query := range db.Queries() {
+		if !strings.Contains(query.SQL, "FROM `daily_summaries`") {
+			continue
+		}
+		if len(query.Args) != 2 || query.Args[1] != string(today) {
+			t.Errorf("summaries queried with %v, want days up to %s", query.Args, today)
+		}
+		return
+	}
+	t.Error("daily summaries were not queried")
+}
@@ -86,15 +86,42 @@ api.interceptors.request.use(
   }
 );
 
+// Exchange the stored refresh token for a new access token
+const refreshAccessToken = async () => {
+  const refreshToken = await storage.getItem('refreshToken');
+  if (!refreshToken) {
+    throw new Error('No refresh token available');
+  }
+
+  const response = await axios.post(`${API_URL}/token/refresh`, {
+    refresh_token: refreshToken
+  }, { withCredentials: true });
+
+  const { token, refresh_token } = response.data;
+  await storage.setItem('userToken', token);
+  await storage.setItem('refreshToken', refresh_token);
+  api.defaults.headers.common['Authorization'] = `Bearer ${token}`;
+  return token;
+};
+
 // Add response interceptor to handle token expiration
 api.interceptors.response.use(
   (response) => response,
   async (error) => {
-    if (error.response?.status === 401) {
-      // Token expired or invalid
-      await storage.removeItem('userToken');
-      await storage.removeItem('userInfo');
-      // You might want to trigger a logout here
+    const originalRequest = error.config;
+    if (error.response?.status === 401 && originalRequest && !originalRequest._retry) {
+      // Access token expired, try once to refresh it
+      originalRequest._retry = true;
+      try {
+        const token = await refreshAccessToken();
+        originalRequest.headers.Authorization = `Bearer ${token}`;
+        return api(originalRequest);
+      } catch (refreshError) {
+        // Refresh token expired or revoked
+        await storage.removeItem('userToken');
+        await storage.removeItem('refreshToken');
+        await storage.removeItem('userInfo');
+      }
     }
     return Promise.reject(error);
   }
@@ -142,14 +169,15 @@ export const AuthProvider = ({ children }) => {
         password
       });
       
-      const { token, user } = response.data;
+      const { token, refresh_token, user } = response.data;
       
       if (token && user) {
         setUserToken(token);
         setUserInfo(user);
         
-        // Store token and user info securely
+        // Store tokens and user info securely
         await storage.setItem('userToken', token);
+        await storage.setItem('refreshToken', refresh_token);
         await storage.setItem('userInfo', JSON.stringify(user));
         
         // Set the token in axios headers
@@ -197,11 +225,19 @@ export const AuthProvider = ({ children }) => {
   const logout = async () => {
     setIsLoading(true);
     try {
+      // Revoke the session on the server, ignoring failures so the user can always log out locally
+      try {
+        await api.post('/logout');
+      } catch (e) {
+        console.log('Error revoking session', e);
+      }
+
       // Clear token from axios headers
       delete api.defaults.headers.common['Authorization'];
       
       // Clear storage
       await storage.removeItem('userToken');
+      await storage.removeItem('refreshToken');
       await storage.removeItem('userInfo');
       
       // Clear state
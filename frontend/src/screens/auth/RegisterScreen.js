@@ -103,7 +103,8 @@ const RegisterScreen = ({ navigation }) => {
         password,
         firstName,
         lastName,
-        phoneNumber
+        phoneNumber,
+        timezone: Intl.DateTimeFormat().resolvedOptions().timeZone
       };
       
       const result = await register(userData);
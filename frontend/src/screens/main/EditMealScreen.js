@@ -41,7 +41,7 @@ const EditMealScreen = ({ route, navigation }) => {
     if (!calories) {
       setCaloriesError('Calories are required');
       isValid = false;
-    } else if (isNaN(calories) || parseInt(calories) < 0) {
+    } else if (isNaN(calories) || parseFloat(calories) < 0) {
       setCaloriesError('Please enter a valid number');
       isValid = false;
     } else {
@@ -52,7 +52,7 @@ const EditMealScreen = ({ route, navigation }) => {
     if (!proteins) {
       setProteinsError('Protein amount is required');
       isValid = false;
-    } else if (isNaN(proteins) || parseInt(proteins) < 0) {
+    } else if (isNaN(proteins) || parseFloat(proteins) < 0) {
       setProteinsError('Please enter a valid number');
       isValid = false;
     } else {
@@ -63,7 +63,7 @@ const EditMealScreen = ({ route, navigation }) => {
     if (!fats) {
       setFatsError('Fat amount is required');
       isValid = false;
-    } else if (isNaN(fats) || parseInt(fats) < 0) {
+    } else if (isNaN(fats) || parseFloat(fats) < 0) {
       setFatsError('Please enter a valid number');
       isValid = false;
     } else {
@@ -74,7 +74,7 @@ const EditMealScreen = ({ route, navigation }) => {
     if (!carbohydrates) {
       setCarbohydratesError('Carbohydrate amount is required');
       isValid = false;
-    } else if (isNaN(carbohydrates) || parseInt(carbohydrates) < 0) {
+    } else if (isNaN(carbohydrates) || parseFloat(carbohydrates) < 0) {
       setCarbohydratesError('Please enter a valid number');
       isValid = false;
     } else {
@@ -97,10 +97,10 @@ const EditMealScreen = ({ route, navigation }) => {
       setIsLoading(true);
       
       const nutrilogData = {
-        calories: parseInt(calories),
-        proteins: parseInt(proteins),
-        fats: parseInt(fats),
-        carbohydrates: parseInt(carbohydrates),
+        calories: parseFloat(calories),
+        proteins: parseFloat(proteins),
+        fats: parseFloat(fats),
+        carbohydrates: parseFloat(carbohydrates),
         meal_type: mealType,
         meal_time: meal.meal_time,
         meal_date: meal.meal_date,
@@ -42,7 +42,7 @@ const LogMealScreen = ({ navigation }) => {
     if (!calories) {
       setCaloriesError('Calories are required');
       isValid = false;
-    } else if (isNaN(calories) || parseInt(calories) < 0) {
+    } else if (isNaN(calories) || parseFloat(calories) < 0) {
       setCaloriesError('Please enter a valid number');
       isValid = false;
     } else {
@@ -53,7 +53,7 @@ const LogMealScreen = ({ navigation }) => {
     if (!proteins) {
       setProteinsError('Protein amount is required');
       isValid = false;
-    } else if (isNaN(proteins) || parseInt(proteins) < 0) {
+    } else if (isNaN(proteins) || parseFloat(proteins) < 0) {
       setProteinsError('Please enter a valid number');
       isValid = false;
     } else {
@@ -64,7 +64,7 @@ const LogMealScreen = ({ navigation }) => {
     if (!fats) {
       setFatsError('Fat amount is required');
       isValid = false;
-    } else if (isNaN(fats) || parseInt(fats) < 0) {
+    } else if (isNaN(fats) || parseFloat(fats) < 0) {
       setFatsError('Please enter a valid number');
       isValid = false;
     } else {
@@ -75,7 +75,7 @@ const LogMealScreen = ({ navigation }) => {
     if (!carbohydrates) {
       setCarbohydratesError('Carbohydrate amount is required');
       isValid = false;
-    } else if (isNaN(carbohydrates) || parseInt(carbohydrates) < 0) {
+    } else if (isNaN(carbohydrates) || parseFloat(carbohydrates) < 0) {
       setCarbohydratesError('Please enter a valid number');
       isValid = false;
     } else {
@@ -103,14 +103,15 @@ const LogMealScreen = ({ navigation }) => {
       setIsLoading(true);
       
       const now = new Date();
-      const mealTime = now.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit' });
-      const mealDate = now.toISOString().split('T')[0]; // YYYY-MM-DD format
+      const pad = (n) => String(n).padStart(2, '0');
+      const mealTime = `${pad(now.getHours())}:${pad(now.getMinutes())}`; // HH:MM, local time
+      const mealDate = `${now.getFullYear()}-${pad(now.getMonth() + 1)}-${pad(now.getDate())}`; // YYYY-MM-DD, local day
       
       const nutrilogData = {
-        calories: parseInt(calories),
-        proteins: parseInt(proteins),
-        fats: parseInt(fats),
-        carbohydrates: parseInt(carbohydrates),
+        calories: parseFloat(calories),
+        proteins: parseFloat(proteins),
+        fats: parseFloat(fats),
+        carbohydrates: parseFloat(carbohydrates),
         meal_type: mealType,
         meal_time: mealTime,
         meal_date: mealDate,
@@ -97,6 +97,7 @@ const ProfileScreen = () => {
         first_name: firstName,
         last_name: lastName,
         phone_number: phoneNumber,
+        timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
       };
       
       // Only include password if it was changed
@@ -10,51 +10,32 @@ import {
 import { Camera } from 'expo-camera';
 import { BarCodeScanner } from 'expo-barcode-scanner';
 import { Ionicons } from '@expo/vector-icons';
+import { lookupBarcode } from '../../services/NutrilogService';
 
-// This is a mock function to simulate fetching nutrition data from an API
-// In a real app, you would connect to a food database API
+// Look up the scanned product on the backend and return the nutrients of one serving
 const fetchNutritionData = async (barcode) => {
-  // Simulate API call delay
-  await new Promise(resolve => setTimeout(resolve, 1000));
-  
-  // Mock data - in a real app, this would come from an API
-  const mockData = {
-    '5449000000996': { // Coca-Cola
-      name: 'Coca-Cola (330ml)',
-      calories: 139,
-      proteins: 0,
-      carbohydrates: 35,
-      fats: 0
-    },
-    '8710398500395': { // Yogurt
-      name: 'Greek Yogurt (150g)',
-      calories: 150,
-      proteins: 15,
-      carbohydrates: 6,
-      fats: 8
-    },
-    '3017620422003': { // Nutella
-      name: 'Nutella (15g serving)',
-      calories: 80,
-      proteins: 1,
-      carbohydrates: 8.5,
-      fats: 4.5
-    },
-    '5000159459228': { // Snickers
-      name: 'Snickers Bar (50g)',
-      calories: 250,
-      proteins: 4.5,
-      carbohydrates: 30,
-      fats: 12
+  const result = await lookupBarcode(barcode);
+  if (!result.success) {
+    return result;
+  }
+
+  // Use the first serving size if the product has one, otherwise 100 g/ml
+  const { food } = result.data;
+  const serving = food.servings && food.servings.length > 0 ? food.servings[0] : null;
+  const quantity = serving ? serving.quantity : 100;
+  const factor = quantity / 100;
+  const round = (value) => Math.round(value * factor * 10) / 10;
+
+  return {
+    success: true,
+    data: {
+      name: `${food.name} (${serving ? serving.name : `100${food.unit}`})`,
+      calories: Math.round(food.calories_per_100 * factor),
+      proteins: round(food.proteins_per_100),
+      carbohydrates: round(food.carbohydrates_per_100),
+      fats: round(food.fats_per_100)
     }
   };
-  
-  // Return mock data if barcode exists, otherwise simulate not found
-  if (mockData[barcode]) {
-    return { success: true, data: mockData[barcode] };
-  } else {
-    return { success: false, message: 'Product not found in database' };
-  }
 };
 
 const ScanBarcodeScreen = ({ navigation }) => {
@@ -111,3 +111,20 @@ export const deleteNutrilog = async (id) => {
     return { success: false, message };
   }
 };
+
+export const lookupBarcode = async (barcode) => {
+  try {
+    const response = await api.get(`/food/barcode/${barcode}`);
+    return { success: true, data: response.data };
+  } catch (error) {
+    let message;
+    if (error.code === 'ECONNREFUSED' || error.message.includes('Network Error')) {
+      message = 'Cannot connect to the backend server. Please make sure the backend is running.';
+    } else if (error.response?.status === 404) {
+      message = 'Product not found in database';
+    } else {
+      message = error.response?.data?.error || 'Failed to look up barcode. Please try again.';
+    }
+    return { success: false, message };
+  }
+};